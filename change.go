@@ -0,0 +1,45 @@
+package easycfg
+
+import "fmt"
+
+// Change describes a configuration value that differs between two configurations
+type Change struct {
+	Path string      // Dotted YAML path of the value
+	Old  interface{} // Value before the change
+	New  interface{} // Value after the change
+}
+
+// String returns a readable description of the change
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %v -> %v", c.Path, c.Old, c.New)
+}
+
+// DeepCopyValue returns a deep copy of an untyped configuration value,
+// duplicating nested maps and slices so the copy shares no memory with v
+func DeepCopyValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, elem := range val {
+			out[k] = DeepCopyValue(elem)
+		}
+		return out
+	case map[interface{}]interface{}:
+		out := make(map[interface{}]interface{}, len(val))
+		for k, elem := range val {
+			out[k] = DeepCopyValue(elem)
+		}
+		return out
+	case []interface{}:
+		if val == nil {
+			return val
+		}
+		out := make([]interface{}, len(val))
+		for i, elem := range val {
+			out[i] = DeepCopyValue(elem)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,39 @@
+package easycfg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDeepCopyValue(t *testing.T) {
+	original := map[string]interface{}{
+		"name":  "app",
+		"addrs": []interface{}{"a", "b"},
+		"nested": map[string]interface{}{
+			"level": "debug",
+		},
+	}
+
+	copied := DeepCopyValue(original).(map[string]interface{})
+	if !reflect.DeepEqual(original, copied) {
+		t.Fatalf("DeepCopyValue() = %v, expected %v", copied, original)
+	}
+
+	// Modifying the copy must not affect the original
+	copied["addrs"].([]interface{})[0] = "changed"
+	copied["nested"].(map[string]interface{})["level"] = "info"
+	if original["addrs"].([]interface{})[0] != "a" {
+		t.Errorf("original addrs modified through copy: %v", original["addrs"])
+	}
+	if original["nested"].(map[string]interface{})["level"] != "debug" {
+		t.Errorf("original nested map modified through copy: %v", original["nested"])
+	}
+}
+
+func TestChangeString(t *testing.T) {
+	change := Change{Path: "general.server.port", Old: ":9311", New: ":9312"}
+	expected := "general.server.port: :9311 -> :9312"
+	if change.String() != expected {
+		t.Errorf("Change.String() = %q, expected %q", change.String(), expected)
+	}
+}
@@ -0,0 +1,19 @@
+package easycfg
+
+import "strconv"
+
+// Decimal is a number kept as its decimal text, for values such as prices
+// that must be compared and printed exactly rather than as binary floating
+// point. LoadConfig decodes both quoted and unquoted YAML numbers into it;
+// quote values with more than 15 significant digits to keep every digit
+type Decimal string
+
+// String returns the decimal text
+func (d Decimal) String() string {
+	return string(d)
+}
+
+// Float64 parses the decimal as a float64
+func (d Decimal) Float64() (float64, error) {
+	return strconv.ParseFloat(string(d), 64)
+}
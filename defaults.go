@@ -0,0 +1,20 @@
+package easycfg
+
+import (
+	"reflect"
+
+	"github.com/spf13/viper"
+)
+
+// setTagDefaults registers the default tag of every field reachable from a
+// struct type as the default of its key, such as `default:"8080"`,
+// `default:"5s"` for a time.Duration or `default:"a,b"` for a list. Tag values
+// are decoded like values of the file, and are used for keys absent from
+// every other source
+func setTagDefaults(v *viper.Viper, t reflect.Type) {
+	walkLeaves(t, reflect.Value{}, "", func(path string, field reflect.StructField, value reflect.Value) {
+		if def, ok := field.Tag.Lookup("default"); ok {
+			v.SetDefault(path, def)
+		}
+	})
+}
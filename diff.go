@@ -0,0 +1,253 @@
+package easycfg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// diffLine is a line of a diff, prefixed with ' ', '-' or '+'
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// unifiedDiff returns a unified diff from the content on disk to the expected
+// content of the file at path, or an empty string if they are equal
+func unifiedDiff(path, current, expected string) string {
+	lines := diffLines(splitLines(current), splitLines(expected))
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("--- %s\n", path))
+	sb.WriteString(fmt.Sprintf("+++ %s (generated)\n", path))
+
+	changed := false
+	for start := 0; start < len(lines); {
+		// Find the next change
+		for start < len(lines) && lines[start].op == ' ' {
+			start++
+		}
+		if start == len(lines) {
+			break
+		}
+		changed = true
+
+		// Extend the hunk until more than twice the context separates two changes
+		hunkStart := max(start-diffContext, 0)
+		end := start
+		for unchanged := 0; end < len(lines) && unchanged <= 2*diffContext; end++ {
+			if lines[end].op == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+		for end > start && lines[end-1].op == ' ' {
+			end--
+		}
+		hunkEnd := min(end+diffContext, len(lines))
+
+		writeHunk(&sb, lines, hunkStart, hunkEnd)
+		start = hunkEnd
+	}
+
+	if !changed {
+		return ""
+	}
+	return sb.String()
+}
+
+// writeHunk writes lines[start:end] with a hunk header
+func writeHunk(sb *strings.Builder, lines []diffLine, start, end int) {
+	// Line numbers before the hunk in the current and expected content
+	oldLine, newLine := 0, 0
+	for _, l := range lines[:start] {
+		if l.op != '+' {
+			oldLine++
+		}
+		if l.op != '-' {
+			newLine++
+		}
+	}
+
+	oldCount, newCount := 0, 0
+	for _, l := range lines[start:end] {
+		if l.op != '+' {
+			oldCount++
+		}
+		if l.op != '-' {
+			newCount++
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount)))
+	for _, l := range lines[start:end] {
+		sb.WriteString(fmt.Sprintf("%c%s\n", l.op, l.text))
+	}
+}
+
+// hunkRange formats the start line and line count of one side of a hunk
+func hunkRange(before, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", before+1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
+
+// diffLines computes a shortest line diff from a to b with Myers' algorithm,
+// in space linear in the number of lines. Within every run of changed lines,
+// removed lines come before added lines
+func diffLines(a, b []string) []diffLine {
+	lines := appendDiff(nil, a, b)
+	for start := 0; start < len(lines); {
+		if lines[start].op == ' ' {
+			start++
+			continue
+		}
+		end := start
+		for end < len(lines) && lines[end].op != ' ' {
+			end++
+		}
+		run := lines[start:end]
+		sort.SliceStable(run, func(i, j int) bool {
+			return run[i].op == '-' && run[j].op == '+'
+		})
+		start = end
+	}
+	return lines
+}
+
+// appendDiff appends a line diff from a to b to lines, matching the lines
+// common to their start and end before splitting the rest in two halves
+func appendDiff(lines []diffLine, a, b []string) []diffLine {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	for _, line := range a[:prefix] {
+		lines = append(lines, diffLine{' ', line})
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if x, y, ok := middleSnake(midA, midB); ok {
+		lines = appendDiff(lines, midA[:x], midB[:y])
+		lines = appendDiff(lines, midA[x:], midB[y:])
+	} else {
+		for _, line := range midA {
+			lines = append(lines, diffLine{'-', line})
+		}
+		for _, line := range midB {
+			lines = append(lines, diffLine{'+', line})
+		}
+	}
+	for _, line := range a[len(a)-suffix:] {
+		lines = append(lines, diffLine{' ', line})
+	}
+	return lines
+}
+
+// middleSnake returns the point where a shortest edit path from a to b,
+// searched from both ends at once, splits in two. It reports false if the
+// lines cannot be split, such as when either is empty
+func middleSnake(a, b []string) (int, int, bool) {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return 0, 0, false
+	}
+
+	// forward[offset+k] and backward[offset+k] are the furthest x reached on
+	// diagonal k = x-y from the start and, in reverse, from the end
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	forward := make([]int, 2*maxD+2)
+	backward := make([]int, 2*maxD+2)
+	for i := range forward {
+		forward[i], backward[i] = -1, -1
+	}
+	forward[offset+1], backward[offset+1] = 0, 0
+
+	delta := n - m
+	odd := delta%2 != 0
+	// Diagonals trimmed from either side after leaving the grid
+	fStart, fEnd, bStart, bEnd := 0, 0, 0, 0
+	for d := 0; d < maxD; d++ {
+		for k := -d + fStart; k <= d-fEnd; k += 2 {
+			var x int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case odd:
+				if i := offset + delta - k; i >= 0 && i < len(backward) && backward[i] != -1 && x >= n-backward[i] {
+					return splitPoint(x, y, n, m)
+				}
+			}
+		}
+
+		for k := -d + bStart; k <= d-bEnd; k += 2 {
+			var x int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+			switch {
+			case x > n:
+				bEnd += 2
+			case y > m:
+				bStart += 2
+			case !odd:
+				if i := offset + delta - k; i >= 0 && i < len(forward) && forward[i] != -1 && forward[i] >= n-x {
+					fx := forward[i]
+					return splitPoint(fx, fx-(delta-k), n, m)
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// splitPoint returns a split point of middleSnake, reporting false for the
+// ends, which would not make the halves any smaller
+func splitPoint(x, y, n, m int) (int, int, bool) {
+	if x+y == 0 || x == n && y == m {
+		return 0, 0, false
+	}
+	return x, y, true
+}
+
+// splitLines splits content into lines without their line terminators
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
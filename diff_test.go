@@ -0,0 +1,133 @@
+package easycfg
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	current := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n"
+	expected := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+
+	// Changes far apart are reported in separate hunks
+	want := `--- app.go
++++ app.go (generated)
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -10,3 +10,4 @@
+ j
+ k
+ l
++m
+`
+	if got := unifiedDiff("app.go", current, expected); got != want {
+		t.Errorf("unifiedDiff() =\n%s\nexpected\n%s", got, want)
+	}
+
+	// Equal content has no diff
+	if got := unifiedDiff("app.go", current, current); got != "" {
+		t.Errorf("unifiedDiff() of equal content = %q, expected empty", got)
+	}
+
+	// A missing file is diffed against empty content
+	want = "--- app.go\n+++ app.go (generated)\n@@ -0,0 +1,2 @@\n+a\n+b\n"
+	if got := unifiedDiff("app.go", "", "a\nb\n"); got != want {
+		t.Errorf("unifiedDiff() of new file =\n%s\nexpected\n%s", got, want)
+	}
+}
+
+func TestUnifiedDiffLargeFile(t *testing.T) {
+	// Changes far apart in a large file, such as a new key in the key
+	// constants and in the structs, are diffed in linear space
+	var lines []string
+	for i := 0; i < 200000; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	current := strings.Join(lines, "\n") + "\n"
+	lines[10] = "changed"
+	lines = append(lines[:199990], append([]string{"added"}, lines[199990:]...)...)
+	expected := strings.Join(lines, "\n") + "\n"
+
+	want := `--- app.go
++++ app.go (generated)
+@@ -8,7 +8,7 @@
+ line 7
+ line 8
+ line 9
+-line 10
++changed
+ line 11
+ line 12
+ line 13
+@@ -199988,6 +199988,7 @@
+ line 199987
+ line 199988
+ line 199989
++added
+ line 199990
+ line 199991
+ line 199992
+`
+	if got := unifiedDiff("app.go", current, expected); got != want {
+		t.Errorf("unifiedDiff() =\n%s\nexpected\n%s", got, want)
+	}
+}
+
+func TestDiffLinesShortest(t *testing.T) {
+	// Diffs of random content apply cleanly and keep a longest common subsequence
+	rng := rand.New(rand.NewSource(1))
+	randomLines := func() []string {
+		lines := make([]string, rng.Intn(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + rng.Intn(4)))
+		}
+		return lines
+	}
+	for i := 0; i < 1000; i++ {
+		a, b := randomLines(), randomLines()
+		var gotA, gotB []string
+		common := 0
+		for _, l := range diffLines(a, b) {
+			if l.op != '+' {
+				gotA = append(gotA, l.text)
+			}
+			if l.op != '-' {
+				gotB = append(gotB, l.text)
+			}
+			if l.op == ' ' {
+				common++
+			}
+		}
+		if strings.Join(gotA, "") != strings.Join(a, "") || strings.Join(gotB, "") != strings.Join(b, "") {
+			t.Fatalf("diffLines(%q, %q) does not apply", a, b)
+		}
+		if expected := lcsLength(a, b); common != expected {
+			t.Fatalf("diffLines(%q, %q) keeps %d lines, expected %d", a, b, common, expected)
+		}
+	}
+}
+
+// lcsLength returns the length of the longest common subsequence of a and b
+func lcsLength(a, b []string) int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	return lcs[0][0]
+}
@@ -0,0 +1,134 @@
+package easycfg
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ConfigExtensions lists the extensions FindConfig tries in every directory, in order
+var ConfigExtensions = []string{"yml", "yaml", "json", "toml"}
+
+// WithAppName makes FindConfig search the <app> directories of
+// $XDG_CONFIG_HOME and /etc instead of those named after the configuration
+func WithAppName(app string) Option {
+	return func(o *loadOptions) {
+		o.appName = app
+	}
+}
+
+// WithSearchPaths makes FindConfig search the given directories, in order,
+// instead of the default ones
+func WithSearchPaths(dirs ...string) Option {
+	return func(o *loadOptions) {
+		o.searchPaths = append(o.searchPaths, dirs...)
+	}
+}
+
+// FindConfig returns the path of the configuration file name, such as
+// "config", trying every extension of ConfigExtensions in the working
+// directory and its parents up to the root of the repository, then in
+// $XDG_CONFIG_HOME/<app> (~/.config/<app> if unset) and /etc/<app>, where app
+// is set with WithAppName and is name by default. If no file is found, the
+// returned *ConfigNotFoundError lists every location checked and the errors,
+// such as permission errors, of those that could not be checked
+func FindConfig(name string, opts ...Option) (string, error) {
+	options := newLoadOptions(opts)
+
+	dirs := options.searchPaths
+	if len(dirs) == 0 {
+		app := options.appName
+		if app == "" {
+			app = name
+		}
+		var err error
+		if dirs, err = defaultSearchPaths(app, options); err != nil {
+			return "", fmt.Errorf("failed to find configuration file: %w", err)
+		}
+	}
+
+	notFound := &ConfigNotFoundError{Name: name}
+	for _, dir := range dirs {
+		for _, ext := range ConfigExtensions {
+			path := filepath.Join(dir, name+"."+ext)
+			if options.fsys != nil {
+				path = filepath.ToSlash(path)
+			}
+			info, err := statConfig(path, options)
+			if err == nil && !info.IsDir() {
+				return path, nil
+			}
+			// Locations that cannot be checked do not stop the search
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				notFound.Errs = append(notFound.Errs, err)
+			}
+			notFound.Paths = append(notFound.Paths, path)
+		}
+	}
+	return "", notFound
+}
+
+// DiscoverConfig finds the configuration file name with FindConfig and loads
+// it into configStruct with LoadConfig, returning the path of the file used
+func DiscoverConfig(name string, configStruct interface{}, opts ...Option) (string, error) {
+	path, err := FindConfig(name, opts...)
+	if err != nil {
+		return "", err
+	}
+	return path, LoadConfig(path, configStruct, opts...)
+}
+
+// defaultSearchPaths returns the directories FindConfig searches by default.
+// Configuration read from WithFS is only searched for in its root directory
+func defaultSearchPaths(app string, options *loadOptions) ([]string, error) {
+	if options.fsys != nil {
+		return []string{"."}, nil
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	dirs := []string{wd}
+	if root, ok := repositoryRoot(wd); ok {
+		for dir := wd; dir != root; {
+			dir = filepath.Dir(dir)
+			dirs = append(dirs, dir)
+		}
+	}
+
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			configHome = filepath.Join(home, ".config")
+		}
+	}
+	if configHome != "" {
+		dirs = append(dirs, filepath.Join(configHome, app))
+	}
+	return append(dirs, filepath.Join("/etc", app)), nil
+}
+
+// repositoryRoot returns the closest directory from dir upward holding a .git entry
+func repositoryRoot(dir string) (string, bool) {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// statConfig describes a configuration file in the file system of WithFS or the operating system
+func statConfig(path string, options *loadOptions) (fs.FileInfo, error) {
+	if options.fsys != nil {
+		return fs.Stat(options.fsys, path)
+	}
+	return os.Stat(path)
+}
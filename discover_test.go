@@ -0,0 +1,136 @@
+package easycfg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFindConfig(t *testing.T) {
+	tempDir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to resolve temporary directory: %v", err)
+	}
+	repo := filepath.Join(tempDir, "repo")
+	work := filepath.Join(repo, "services", "api")
+	configHome := filepath.Join(tempDir, "xdg")
+	for _, dir := range []string{filepath.Join(repo, ".git"), work, filepath.Join(configHome, "myapp")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(repo, "app.toml"):                   "[server]\nport = 7070\n",
+		filepath.Join(configHome, "myapp", "config.json"): `{"server": {"port": 8080}}`,
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", path, err)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(work); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	defer os.Chdir(wd)
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+
+	// Parents are searched up to the repository root
+	var cfg struct {
+		Server struct {
+			Port int `mapstructure:"port"`
+		} `mapstructure:"server"`
+	}
+	path, err := DiscoverConfig("app", &cfg)
+	if err != nil {
+		t.Fatalf("DiscoverConfig failed: %v", err)
+	}
+	if path != filepath.Join(repo, "app.toml") || cfg.Server.Port != 7070 {
+		t.Errorf("DiscoverConfig found %s with port %d", path, cfg.Server.Port)
+	}
+
+	// The XDG directory is named after the application
+	path, err = FindConfig("config", WithAppName("myapp"))
+	if err != nil {
+		t.Fatalf("FindConfig failed: %v", err)
+	}
+	if expected := filepath.Join(configHome, "myapp", "config.json"); path != expected {
+		t.Errorf("FindConfig() = %s, expected %s", path, expected)
+	}
+
+	// Every checked location is reported
+	_, err = FindConfig("missing")
+	var notFound *ConfigNotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("Expected *ConfigNotFoundError, got %v", err)
+	}
+	expected := []string{
+		filepath.Join(work, "missing.yml"),
+		filepath.Join(repo, "services", "missing.yml"),
+		filepath.Join(repo, "missing.yml"),
+		filepath.Join(configHome, "missing", "missing.yml"),
+		filepath.Join("/etc", "missing", "missing.yml"),
+	}
+	if len(notFound.Paths) != len(expected)*len(ConfigExtensions) {
+		t.Fatalf("Checked %d locations, expected %d: %v", len(notFound.Paths), len(expected)*len(ConfigExtensions), notFound.Paths)
+	}
+	for i, path := range expected {
+		if notFound.Paths[i*len(ConfigExtensions)] != path {
+			t.Errorf("Location %d = %s, expected %s", i, notFound.Paths[i*len(ConfigExtensions)], path)
+		}
+	}
+
+	// Search paths replace the defaults, and WithFS is searched instead of the disk
+	if _, err := FindConfig("app", WithSearchPaths(work)); err == nil {
+		t.Errorf("Expected error for a configuration outside the search paths")
+	}
+	fsys := fstest.MapFS{"conf/app.yaml": {Data: []byte("server:\n  port: 9090\n")}}
+	path, err = DiscoverConfig("app", &cfg, WithFS(fsys), WithSearchPaths("conf"))
+	if err != nil {
+		t.Fatalf("DiscoverConfig failed: %v", err)
+	}
+	if path != "conf/app.yaml" || cfg.Server.Port != 9090 {
+		t.Errorf("DiscoverConfig found %s with port %d", path, cfg.Server.Port)
+	}
+}
+
+func TestFindConfigUncheckedLocation(t *testing.T) {
+	tempDir := t.TempDir()
+	notDir := filepath.Join(tempDir, "file")
+	if err := os.WriteFile(notDir, nil, 0644); err != nil {
+		t.Fatalf("Failed to create %s: %v", notDir, err)
+	}
+	configDir := filepath.Join(tempDir, "conf")
+	if err := os.Mkdir(configDir, 0755); err != nil {
+		t.Fatalf("Failed to create %s: %v", configDir, err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "app.yml"), nil, 0644); err != nil {
+		t.Fatalf("Failed to create configuration file: %v", err)
+	}
+
+	// A location that cannot be checked does not stop the search
+	path, err := FindConfig("app", WithSearchPaths(notDir, configDir))
+	if err != nil {
+		t.Fatalf("FindConfig failed: %v", err)
+	}
+	if expected := filepath.Join(configDir, "app.yml"); path != expected {
+		t.Errorf("FindConfig() = %s, expected %s", path, expected)
+	}
+
+	// Its error is reported if no file is found
+	_, err = FindConfig("app", WithSearchPaths(notDir))
+	if !errors.Is(err, ErrConfigNotFound) || !errors.Is(err, syscall.ENOTDIR) {
+		t.Fatalf("Expected ErrConfigNotFound wrapping ENOTDIR, got %v", err)
+	}
+	var notFound *ConfigNotFoundError
+	if !errors.As(err, &notFound) || len(notFound.Errs) != len(ConfigExtensions) {
+		t.Errorf("Expected an error per extension, got %v", err)
+	}
+}
@@ -0,0 +1,455 @@
+package easycfg
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DocsFormat selects the output format of YamlToDocs and StructToDocs
+type DocsFormat string
+
+const (
+	DocsMarkdown DocsFormat = "markdown" // A Markdown page with a table per section
+	DocsHTML     DocsFormat = "html"     // A standalone HTML page with a table per section
+)
+
+// markdownTemplate renders reference documentation as Markdown
+//
+//go:embed templates/markdown.tmpl
+var markdownTemplate string
+
+// htmlTemplate renders reference documentation as HTML
+//
+//go:embed templates/html.tmpl
+var htmlTemplate string
+
+// WithDocsEnvPrefix makes YamlToDocs and StructToDocs prefix the environment
+// variable names they list with prefix, such as APP_GENERAL_SERVER_PORT
+func WithDocsEnvPrefix(prefix string) GenerateOption {
+	return func(o *generateOptions) {
+		o.docsEnvPrefix = prefix
+	}
+}
+
+// docsModel is the data rendered by the documentation templates
+type docsModel struct {
+	Title    string         // Name of the configuration
+	Source   string         // File name of the source YAML, empty for Go structs
+	Sections []*docsSection // Sections in document order, the top-level keys first
+}
+
+// docsSection lists the values of a mapping
+type docsSection struct {
+	Path    string // Dotted key path of the mapping, empty for the top-level keys
+	Entries []*docsEntry
+}
+
+// docsEntry documents a single configuration value
+type docsEntry struct {
+	Path        string   // Dotted key path, with [] marking the elements of a list
+	Type        string   // Type of the value
+	Default     string   // Default value
+	EnvVar      string   // Environment variable name, empty for values inside lists
+	Description string   // Description from the YAML comment or doc tag
+	Enum        []string // Allowed values of an enum
+}
+
+// YamlToDocs renders reference documentation of every key in a YAML file,
+// with its type, sample value as the default, environment variable name,
+// comment and enum values. Directives from comments and from WithMappingFile
+// apply as in YamlToStruct. Values of secrets, including keys that look
+// secret, are always redacted
+func YamlToDocs(yamlFilePath string, format DocsFormat, opts ...GenerateOption) ([]byte, error) {
+	options := newGenerateOptions(opts)
+
+	// Read YAML file
+	yamlData, err := os.ReadFile(yamlFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read YAML file: %v", err)
+	}
+
+	mapping, err := loadMappingFile(options.mappingFile)
+	if err != nil {
+		return nil, err
+	}
+
+	baseName := filepath.Base(yamlFilePath)
+	structName := toCamelCase(strings.TrimSuffix(baseName, filepath.Ext(baseName)))
+	model, err := buildModel(yamlData, baseName, structName, "", options, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := &docsModel{Title: structName, Source: baseName}
+	b := &modelDocsBuilder{model: model, docs: docs, envPrefix: options.docsEnvPrefix, structs: map[string]*StructDef{}}
+	for _, sd := range model.Structs {
+		b.structs[sd.Name] = sd
+	}
+	b.walk(model.Root, "", false, false)
+	return renderDocs(docs, format)
+}
+
+// StructToDocs renders reference documentation of every field of a
+// configuration struct, with its type, the value it holds or its default tag
+// as the default, environment variable name, doc tag and enum values
+func StructToDocs(configStruct interface{}, format DocsFormat, opts ...GenerateOption) ([]byte, error) {
+	options := newGenerateOptions(opts)
+
+	v := reflect.ValueOf(configStruct)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("failed to document configuration: %T is not a struct", configStruct)
+	}
+
+	docs := &docsModel{Title: v.Type().Name()}
+	b := &structDocsBuilder{docs: docs, envPrefix: options.docsEnvPrefix, onPath: map[reflect.Type]bool{}}
+	b.walk(v.Type(), v, "", false, false, b.section(""))
+	return renderDocs(docs, format)
+}
+
+// renderDocs executes the documentation template of a format
+func renderDocs(docs *docsModel, format DocsFormat) ([]byte, error) {
+	var buf bytes.Buffer
+	switch format {
+	case DocsMarkdown, "":
+		tmpl, err := template.New("markdown.tmpl").Funcs(template.FuncMap{
+			"code": markdownCode,
+			"cell": markdownCell,
+		}).Parse(markdownTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse docs template: %v", err)
+		}
+		if err := tmpl.Execute(&buf, docs); err != nil {
+			return nil, fmt.Errorf("failed to execute docs template: %v", err)
+		}
+	case DocsHTML:
+		tmpl, err := htmltemplate.New("html.tmpl").Parse(htmlTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse docs template: %v", err)
+		}
+		if err := tmpl.Execute(&buf, docs); err != nil {
+			return nil, fmt.Errorf("failed to execute docs template: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported docs format %q, must be one of: %s, %s", format, DocsMarkdown, DocsHTML)
+	}
+	return buf.Bytes(), nil
+}
+
+// modelDocsBuilder collects documentation from the model of a YAML file
+type modelDocsBuilder struct {
+	model     *Model
+	docs      *docsModel
+	envPrefix string
+	structs   map[string]*StructDef
+}
+
+// walk documents the fields of a struct and then descends into its nested
+// structs. secret reports whether a parent key is secret
+func (b *modelDocsBuilder) walk(sd *StructDef, path string, inList, secret bool) {
+	section := &docsSection{Path: path}
+	b.docs.Sections = append(b.docs.Sections, section)
+
+	type child struct {
+		sd     *StructDef
+		path   string
+		inList bool
+		secret bool
+	}
+	var children []child
+	for _, f := range sd.Fields {
+		fieldPath := joinPath(path, f.Key)
+		fieldSecret := secret || isSecretField(f)
+
+		// Values inside lists and maps of mappings are documented with [] and .* in their path
+		inner, childPath := f.Type, fieldPath
+		for inner.Kind == KindSlice || inner.Kind == KindMap {
+			if inner.Kind == KindSlice {
+				childPath += "[]"
+			} else {
+				childPath += ".*"
+			}
+			inner = inner.Elem
+		}
+		if inner.Kind == KindStruct {
+			children = append(children, child{b.structs[inner.Name], childPath, inList || childPath != fieldPath, fieldSecret})
+			continue
+		}
+
+		entry := &docsEntry{
+			Path:        fieldPath,
+			Type:        b.docType(f.Type),
+			Default:     f.Sample,
+			Description: f.Comment,
+			Enum:        b.enumValues(f.Type),
+		}
+		if !inList {
+			entry.EnvVar = envVarName(b.envPrefix, defaultEnvSeparator, fieldPath)
+		}
+		if fieldSecret && entry.Default != "" {
+			entry.Default = redacted
+		}
+		section.Entries = append(section.Entries, entry)
+	}
+
+	for _, c := range children {
+		b.walk(c.sd, c.path, c.inList, c.secret)
+	}
+}
+
+// isSecretField reports whether the sample value of a field must be redacted:
+// secrets typed as such, marked with a directive or with a key that looks
+// secret, whether or not secret detection is enabled
+func isSecretField(f *FieldDef) bool {
+	if value, ok := f.Directives["secret"]; ok {
+		return value != "false"
+	}
+	return isSecretType(f.Type) || isSecretKey(f.Key)
+}
+
+// docType returns the documented type of a value
+func (b *modelDocsBuilder) docType(t *TypeRef) string {
+	switch t.Kind {
+	case KindSlice:
+		return "list of " + b.docType(t.Elem)
+	case KindMap:
+		return "map of " + b.docType(t.Elem)
+	case KindAny:
+		return "any"
+	}
+	switch {
+	case t.Name == secretType:
+		return "string (secret)"
+	case t.Name == decimalType:
+		return "decimal"
+	case b.enumValues(t) != nil:
+		return "string (enum)"
+	}
+	return t.Name
+}
+
+// enumValues returns the allowed values of an enum, or of the elements of a list or map of enums
+func (b *modelDocsBuilder) enumValues(t *TypeRef) []string {
+	t = elemType(t)
+	for _, e := range b.model.Enums {
+		if e.Name == t.Name {
+			values := make([]string, len(e.Values))
+			for i, v := range e.Values {
+				values[i] = v.Value
+			}
+			return values
+		}
+	}
+	return nil
+}
+
+// isSecretType reports whether a value, or the elements of a list or map, are secrets
+func isSecretType(t *TypeRef) bool {
+	return elemType(t).Name == secretType
+}
+
+// structDocsBuilder collects documentation from the fields of a Go struct
+type structDocsBuilder struct {
+	docs      *docsModel
+	envPrefix string
+	onPath    map[reflect.Type]bool // Struct types being walked, skipped by self-referential fields
+}
+
+// Types with special meaning in configuration structs
+var (
+	enumType        = reflect.TypeOf((*Enum)(nil)).Elem()
+	secretValueType = reflect.TypeOf(Secret(""))
+	decimalValue    = reflect.TypeOf(Decimal(""))
+	timeType        = reflect.TypeOf(time.Time{})
+)
+
+// section appends a new section to the documentation
+func (b *structDocsBuilder) section(path string) *docsSection {
+	section := &docsSection{Path: path}
+	b.docs.Sections = append(b.docs.Sections, section)
+	return section
+}
+
+// walk documents the fields of a struct type, reading defaults from v if it is valid,
+// and then descends into its nested structs. secret reports whether a parent key is secret
+func (b *structDocsBuilder) walk(t reflect.Type, v reflect.Value, path string, inList, secret bool, section *docsSection) {
+	type child struct {
+		t      reflect.Type
+		v      reflect.Value
+		path   string
+		inList bool
+		secret bool
+	}
+	if b.onPath[t] {
+		return
+	}
+	b.onPath[t] = true
+	defer delete(b.onPath, t)
+
+	var children []child
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		key, squash := fieldKey(field)
+		if key == "-" {
+			continue
+		}
+
+		ft := field.Type
+		var fv reflect.Value
+		if v.IsValid() {
+			fv = v.Field(i)
+		}
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+			if fv.IsValid() {
+				fv = fv.Elem()
+			}
+		}
+
+		// Squashed structs document their fields as part of the parent
+		if squash && ft.Kind() == reflect.Struct {
+			b.walk(ft, fv, path, inList, secret, section)
+			continue
+		}
+
+		// Values inside lists and maps of structs are documented with [] and .* in their path
+		fieldPath := joinPath(path, key)
+		fieldSecret := secret || ft == secretValueType || isSecretKey(key)
+		inner, childPath := ft, fieldPath
+		for inner.Kind() == reflect.Slice || inner.Kind() == reflect.Array || inner.Kind() == reflect.Map {
+			if inner.Kind() == reflect.Map {
+				childPath += ".*"
+			} else {
+				childPath += "[]"
+			}
+			inner = inner.Elem()
+		}
+		if childPath != fieldPath && isNestedStruct(inner) {
+			for inner.Kind() == reflect.Ptr {
+				inner = inner.Elem()
+			}
+			children = append(children, child{inner, reflect.Value{}, childPath, true, fieldSecret})
+			continue
+		}
+		if isNestedStruct(ft) {
+			children = append(children, child{ft, fv, fieldPath, inList, fieldSecret})
+			continue
+		}
+
+		entry := &docsEntry{
+			Path:        fieldPath,
+			Type:        reflectDocType(ft),
+			Description: field.Tag.Get("doc"),
+			Enum:        reflectEnumValues(ft),
+		}
+		if fv.IsValid() && !fv.IsZero() {
+			entry.Default = formatDefault(fv)
+		} else if def, ok := field.Tag.Lookup("default"); ok {
+			entry.Default = def
+		}
+		if fieldSecret && entry.Default != "" {
+			entry.Default = redacted
+		}
+		if !inList {
+			entry.EnvVar = envVarName(b.envPrefix, defaultEnvSeparator, fieldPath)
+		}
+		section.Entries = append(section.Entries, entry)
+	}
+
+	for _, c := range children {
+		if b.onPath[c.t] {
+			continue
+		}
+		b.walk(c.t, c.v, c.path, c.inList, c.secret, b.section(c.path))
+	}
+}
+
+// isNestedStruct reports whether a type is documented as a nested mapping
+func isNestedStruct(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != timeType && !t.Implements(enumType)
+}
+
+// reflectDocType returns the documented type of a struct field
+func reflectDocType(t reflect.Type) string {
+	switch {
+	case t == secretValueType:
+		return "string (secret)"
+	case t == decimalValue:
+		return "decimal"
+	case t == durationType:
+		return "duration"
+	case t == timeType:
+		return "time"
+	case t.Implements(enumType):
+		return "string (enum)"
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return "list of " + reflectDocType(t.Elem())
+	case reflect.Map:
+		return "map of " + reflectDocType(t.Elem())
+	case reflect.Interface:
+		return "any"
+	case reflect.Ptr:
+		return reflectDocType(t.Elem())
+	}
+	return t.Kind().String()
+}
+
+// reflectEnumValues returns the allowed values of an enum type, or of the elements of a list or map of enums
+func reflectEnumValues(t reflect.Type) []string {
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if !t.Implements(enumType) {
+		return nil
+	}
+	return reflect.Zero(t).Interface().(Enum).EnumValues()
+}
+
+// formatDefault formats a default value as it would be written in YAML,
+// relying on fmt to redact secrets
+func formatDefault(v reflect.Value) string {
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		values := make([]string, v.Len())
+		for i := range values {
+			values[i] = formatDefault(v.Index(i))
+		}
+		return "[" + strings.Join(values, ", ") + "]"
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// markdownCode formats text as inline code in a Markdown table cell
+func markdownCode(s string) string {
+	switch {
+	case s == "":
+		return ""
+	case strings.Contains(s, "`"):
+		return "`` " + markdownCell(s) + " ``"
+	default:
+		return "`" + markdownCell(s) + "`"
+	}
+}
+
+// markdownCell escapes text for a Markdown table cell
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
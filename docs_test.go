@@ -0,0 +1,181 @@
+package easycfg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestYamlToDocs(t *testing.T) {
+	// Create test YAML file
+	yamlContent := `
+# Service name
+name: app
+general:
+  server:
+    # Listen port
+    port: 8080
+  password: hunter2 # easycfg:secret
+logger:
+  level: info # easycfg:enum=debug,info
+servers:
+  - host: x # Host | name
+`
+	tempDir := t.TempDir()
+	yamlPath := filepath.Join(tempDir, "app.yml")
+	if err := os.WriteFile(yamlPath, []byte(yamlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test YAML file: %v", err)
+	}
+
+	docs, err := YamlToDocs(yamlPath, DocsMarkdown, WithDocsEnvPrefix("app"))
+	if err != nil {
+		t.Fatalf("YamlToDocs failed: %v", err)
+	}
+	for _, expected := range []string{
+		"# App configuration reference",
+		"| `name` | string | `app` | `APP_NAME` | Service name |",
+		"## `general.server`",
+		"| `general.server.port` | int | `8080` | `APP_GENERAL_SERVER_PORT` | Listen port |",
+		"| `general.password` | string (secret) | `[REDACTED]` | `APP_GENERAL_PASSWORD` |  |",
+		"| `logger.level` | string (enum) | `info` | `APP_LOGGER_LEVEL` | One of: `debug`, `info` |",
+		"| `servers[].host` | string | `x` |  | Host \\| name |",
+	} {
+		if !strings.Contains(string(docs), expected) {
+			t.Errorf("Markdown docs are missing expected content: %s", expected)
+		}
+	}
+	if strings.Contains(string(docs), "hunter2") {
+		t.Errorf("Markdown docs leak a secret value")
+	}
+
+	docs, err = YamlToDocs(yamlPath, DocsHTML)
+	if err != nil {
+		t.Fatalf("YamlToDocs failed: %v", err)
+	}
+	for _, expected := range []string{
+		"<h2><code>general.server</code></h2>",
+		"<tr><td><code>general.server.port</code></td><td>int</td><td><code>8080</code></td><td><code>GENERAL_SERVER_PORT</code></td>",
+		"One of: <code>debug</code>, <code>info</code>",
+	} {
+		if !strings.Contains(string(docs), expected) {
+			t.Errorf("HTML docs are missing expected content: %s", expected)
+		}
+	}
+
+	if _, err := YamlToDocs(yamlPath, "pdf"); err == nil {
+		t.Errorf("Expected error for unsupported docs format")
+	}
+}
+
+func TestYamlToDocsSecretKeys(t *testing.T) {
+	yamlContent := `
+db:
+  password: hunter2
+  api_token: 123456
+  password_file: /etc/pw # easycfg:secret=false
+secret:
+  user: admin
+`
+	tempDir := t.TempDir()
+	yamlPath := filepath.Join(tempDir, "app.yml")
+	if err := os.WriteFile(yamlPath, []byte(yamlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test YAML file: %v", err)
+	}
+
+	// Keys that look secret are redacted without WithSecretDetection
+	docs, err := YamlToDocs(yamlPath, DocsMarkdown)
+	if err != nil {
+		t.Fatalf("YamlToDocs failed: %v", err)
+	}
+	for _, expected := range []string{
+		"| `db.password` | string | `[REDACTED]` | `DB_PASSWORD` |  |",
+		"| `db.api_token` | int | `[REDACTED]` | `DB_API_TOKEN` |  |",
+		"| `db.password_file` | string | `/etc/pw` | `DB_PASSWORD_FILE` |  |",
+		"| `secret.user` | string | `[REDACTED]` | `SECRET_USER` |  |",
+	} {
+		if !strings.Contains(string(docs), expected) {
+			t.Errorf("Markdown docs are missing expected content: %s", expected)
+		}
+	}
+	for _, leaked := range []string{"hunter2", "123456", "admin"} {
+		if strings.Contains(string(docs), leaked) {
+			t.Errorf("Markdown docs leak a secret value: %s", leaked)
+		}
+	}
+}
+
+func TestStructToDocs(t *testing.T) {
+	type server struct {
+		Host string `mapstructure:"host" doc:"Host name" default:"localhost"`
+		Port int    `mapstructure:"port" default:"80"`
+	}
+	type config struct {
+		Name     string    `mapstructure:"name" doc:"Service name"`
+		Password Secret    `mapstructure:"password"`
+		Level    testLevel `mapstructure:"level"`
+		Tags     []string  `mapstructure:"tags"`
+		APIKey   string    `mapstructure:"api_key" default:"abc"`
+		Server   server    `mapstructure:"server"`
+		Replicas []server  `mapstructure:"replicas"`
+	}
+	cfg := &config{Name: "app", Password: "hunter2", Tags: []string{"a", "b"}, Server: server{Port: 8080}}
+
+	docs, err := StructToDocs(cfg, DocsMarkdown)
+	if err != nil {
+		t.Fatalf("StructToDocs failed: %v", err)
+	}
+	for _, expected := range []string{
+		"# config configuration reference",
+		"| `name` | string | `app` | `NAME` | Service name |",
+		"| `password` | string (secret) | `[REDACTED]` | `PASSWORD` |  |",
+		"| `level` | string (enum) |  | `LEVEL` | One of: `debug`, `info` |",
+		"| `tags` | list of string | `[a, b]` | `TAGS` |  |",
+		"| `api_key` | string | `[REDACTED]` | `API_KEY` |  |",
+		"| `server.host` | string | `localhost` | `SERVER_HOST` | Host name |",
+		"| `server.port` | int | `8080` | `SERVER_PORT` |  |",
+		"| `replicas[].host` | string | `localhost` |  | Host name |",
+	} {
+		if !strings.Contains(string(docs), expected) {
+			t.Errorf("Markdown docs are missing expected content: %s", expected)
+		}
+	}
+
+	if _, err := StructToDocs("config", DocsMarkdown); err == nil {
+		t.Errorf("Expected error for a value that is not a struct")
+	}
+}
+
+func TestStructToDocsNamedTypes(t *testing.T) {
+	type node struct {
+		Name string `mapstructure:"name"`
+		Next *node  `mapstructure:"next"`
+	}
+	type config struct {
+		Timeout time.Duration `mapstructure:"timeout" default:"5s"`
+		Started time.Time     `mapstructure:"started"`
+		Price   Decimal       `mapstructure:"price"`
+		Root    node          `mapstructure:"root"`
+	}
+
+	docs, err := StructToDocs(&config{Timeout: 10 * time.Second}, DocsMarkdown)
+	if err != nil {
+		t.Fatalf("StructToDocs failed: %v", err)
+	}
+	for _, expected := range []string{
+		"| `timeout` | duration | `10s` | `TIMEOUT` |  |",
+		"| `started` | time |  | `STARTED` |  |",
+		"| `price` | decimal |  | `PRICE` |  |",
+		"| `root.name` | string |  | `ROOT_NAME` |  |",
+	} {
+		if !strings.Contains(string(docs), expected) {
+			t.Errorf("Markdown docs are missing expected content: %s", expected)
+		}
+	}
+	for _, unexpected := range []string{"## `started`", "root.next"} {
+		if strings.Contains(string(docs), unexpected) {
+			t.Errorf("Markdown docs have unexpected content: %s", unexpected)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package easycfg
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// defaultEnvSeparator joins the prefix and the keys of environment variable names
+const defaultEnvSeparator = "_"
+
+// WithEnv makes LoadConfig and WatchConfig read every field of the struct from
+// an environment variable named after its key path, such as
+// GENERAL_SERVER_PORT for general.server.port, including keys missing from
+// the configuration file. Environment variables take precedence over the
+// file and defaults. Lists of scalars are read from comma-separated values,
+// such as HOSTS=a,b,c, while lists of structs and maps cannot be set
+func WithEnv() Option {
+	return func(o *loadOptions) {
+		o.env = true
+	}
+}
+
+// WithEnvPrefix is WithEnv with names starting with the given prefix, such as
+// APP_GENERAL_SERVER_PORT for general.server.port with prefix "APP"
+func WithEnvPrefix(prefix string) Option {
+	return func(o *loadOptions) {
+		o.env = true
+		o.envPrefix = prefix
+	}
+}
+
+// WithEnvSeparator is WithEnv with the prefix and keys of names joined by
+// separator instead of "_", such as APP__GENERAL__SERVER_PORT with "__",
+// which keeps nesting apparent for keys containing underscores
+func WithEnvSeparator(separator string) Option {
+	return func(o *loadOptions) {
+		o.env = true
+		o.envSeparator = separator
+	}
+}
+
+// bindEnv binds the environment variable of every field reachable from a
+// struct type, so that Viper reads it even for keys missing from the file
+func bindEnv(v *viper.Viper, t reflect.Type, options *loadOptions) {
+	separator := options.envSeparator
+	if separator == "" {
+		separator = defaultEnvSeparator
+	}
+	walkLeaves(t, reflect.Value{}, "", func(path string, field reflect.StructField, value reflect.Value) {
+		v.BindEnv(path, envVarName(options.envPrefix, separator, path))
+	})
+}
+
+// envVarName returns the environment variable name of a dotted key path, such
+// as APP_GENERAL_SERVER_PORT for general.server.port with prefix APP. Keys are
+// joined with separator, and other characters that are not letters or digits
+// become underscores
+func envVarName(prefix, separator, path string) string {
+	keys := strings.Split(path, ".")
+	for i, key := range keys {
+		keys[i] = strings.ToUpper(nonAlphanumericRegexp.ReplaceAllString(key, "_"))
+	}
+	name := strings.Join(keys, separator)
+	if prefix != "" {
+		name = strings.ToUpper(prefix) + separator + name
+	}
+	return name
+}
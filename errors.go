@@ -0,0 +1,225 @@
+package easycfg
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrConfigNotFound is matched by errors.Is for the errors of LoadConfig,
+// WatchConfig and FindConfig when a configuration file does not exist
+var ErrConfigNotFound = errors.New("configuration file not found")
+
+// FieldError describes an invalid value at a configuration key path
+type FieldError struct {
+	Path  string      // Dotted key path of the value
+	Value interface{} // The invalid value
+	Err   error       // Why the value is invalid
+}
+
+// Error returns the key path followed by the reason the value is invalid
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+// Unwrap returns the reason the value is invalid
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError lists every invalid value found in a configuration
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+// Error lists every invalid value on its own line
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Errors))
+	for i, fieldErr := range e.Errors {
+		lines[i] = "  " + fieldErr.Error()
+	}
+	return fmt.Sprintf("invalid configuration:\n%s", strings.Join(lines, "\n"))
+}
+
+// Unwrap returns the invalid values, so that errors.Is and errors.As match their reasons
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, fieldErr := range e.Errors {
+		errs[i] = fieldErr
+	}
+	return errs
+}
+
+// ParseError reports a configuration file that is not valid YAML, JSON or TOML
+type ParseError struct {
+	File    string // Path of the file, empty for WithConfigData
+	Line    int    // Line of the error, starting at 1, or 0 if unknown
+	Column  int    // Column of the error, starting at 1, or 0 if unknown
+	Snippet string // The line of the error, followed by a caret under the column
+	Err     error  // The error of the parser
+}
+
+// Error returns the position of the error and its reason, followed by the snippet
+func (e *ParseError) Error() string {
+	var sb strings.Builder
+	sb.WriteString("failed to parse ")
+	switch {
+	case e.File == "" && e.Line > 0 && e.Column > 0:
+		sb.WriteString(fmt.Sprintf("configuration data at line %d, column %d", e.Line, e.Column))
+	case e.File == "" && e.Line > 0:
+		sb.WriteString(fmt.Sprintf("configuration data at line %d", e.Line))
+	case e.File == "":
+		sb.WriteString("configuration data")
+	case e.Line > 0 && e.Column > 0:
+		sb.WriteString(fmt.Sprintf("%s:%d:%d", e.File, e.Line, e.Column))
+	case e.Line > 0:
+		sb.WriteString(fmt.Sprintf("%s:%d", e.File, e.Line))
+	default:
+		sb.WriteString(e.File)
+	}
+	sb.WriteString(": ")
+	sb.WriteString(parserMessageRegexp.ReplaceAllString(e.Err.Error(), ""))
+	if e.Snippet != "" {
+		sb.WriteString("\n")
+		sb.WriteString(e.Snippet)
+	}
+	return sb.String()
+}
+
+// Unwrap returns the error of the parser
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeError reports a configuration value that cannot be converted to the type of its field
+type DecodeError struct {
+	Path     string // Dotted key path of the value
+	Expected string // Type of the field, such as int, empty if unknown
+	Actual   string // Type of the value, such as string, empty if unknown
+	Value    string // The value, empty if unknown
+	Err      error  // Why the value cannot be converted, nil if only the types are known
+}
+
+// Error returns the key path followed by the expected and actual types
+func (e *DecodeError) Error() string {
+	var sb strings.Builder
+	if e.Path != "" {
+		sb.WriteString(e.Path + ": ")
+	}
+	if e.Expected != "" {
+		sb.WriteString(fmt.Sprintf("expected %s, got %s", e.Expected, e.Actual))
+		if e.Value != "" {
+			sb.WriteString(fmt.Sprintf(" %q", e.Value))
+		}
+		if e.Err != nil {
+			sb.WriteString(": ")
+		}
+	}
+	if e.Err != nil {
+		sb.WriteString(e.Err.Error())
+	}
+	return sb.String()
+}
+
+// Unwrap returns why the value cannot be converted
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// ConfigNotFoundError reports that FindConfig found no configuration file
+type ConfigNotFoundError struct {
+	Name  string   // Name of the configuration, without extension
+	Paths []string // Every location checked, in search order
+	Errs  []error  // Errors of locations that could not be checked, such as permission errors
+}
+
+// Error lists every location checked on its own line, followed by the
+// errors of those that could not be checked
+func (e *ConfigNotFoundError) Error() string {
+	lines := make([]string, len(e.Paths))
+	for i, path := range e.Paths {
+		lines[i] = "  " + path
+	}
+	msg := fmt.Sprintf("configuration file %s not found, checked:\n%s", e.Name, strings.Join(lines, "\n"))
+	if len(e.Errs) > 0 {
+		lines = make([]string, len(e.Errs))
+		for i, err := range e.Errs {
+			lines[i] = "  " + err.Error()
+		}
+		msg += "\nsome locations could not be checked:\n" + strings.Join(lines, "\n")
+	}
+	return msg
+}
+
+// Unwrap returns the errors of the locations that could not be checked
+func (e *ConfigNotFoundError) Unwrap() []error {
+	return e.Errs
+}
+
+// Is reports whether target is ErrConfigNotFound
+func (e *ConfigNotFoundError) Is(target error) bool {
+	return target == ErrConfigNotFound
+}
+
+// EnumError reports a value outside the allowed values of an enum
+type EnumError struct {
+	Value   string
+	Allowed []string
+}
+
+// Error lists the allowed values
+func (e *EnumError) Error() string {
+	return fmt.Sprintf("invalid value %q, must be one of: %s", e.Value, strings.Join(e.Allowed, ", "))
+}
+
+// OutdatedError reports generated files that are missing or differ from what
+// the generator produces for the current source
+type OutdatedError struct {
+	Paths []string // Generated files that are out of date
+	Diff  string   // Unified diff from the files on disk to the expected content
+}
+
+// Error lists the out of date files
+func (e *OutdatedError) Error() string {
+	return fmt.Sprintf("generated files are out of date: %s", strings.Join(e.Paths, ", "))
+}
+
+// TargetError reports a YAML file of a project target that failed to generate
+type TargetError struct {
+	Path string // Path of the YAML file
+	Err  error  // Why generation failed
+}
+
+// Error returns the YAML file followed by the reason generation failed
+func (e *TargetError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+// Unwrap returns the reason generation failed
+func (e *TargetError) Unwrap() error {
+	return e.Err
+}
+
+// ProjectError lists every YAML file of a project that failed to generate
+type ProjectError struct {
+	Action string // What was done to the project, generate or check
+	Errors []*TargetError
+}
+
+// Error lists every failed YAML file on its own line
+func (e *ProjectError) Error() string {
+	lines := make([]string, len(e.Errors))
+	for i, targetErr := range e.Errors {
+		lines[i] = "  " + targetErr.Error()
+	}
+	return fmt.Sprintf("failed to %s project files:\n%s", e.Action, strings.Join(lines, "\n"))
+}
+
+// Unwrap returns the failed YAML files, so that errors.Is and errors.As match their reasons
+func (e *ProjectError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, targetErr := range e.Errors {
+		errs[i] = targetErr
+	}
+	return errs
+}
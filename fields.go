@@ -0,0 +1,152 @@
+package easycfg
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// fieldVisitor is called for every struct field reachable from a configuration struct
+type fieldVisitor func(path string, field reflect.StructField, value reflect.Value)
+
+// walkFields calls visit for every exported field reachable from v, passing the
+// dotted key path Viper uses for it, then descends into nested structs, slices and maps
+func walkFields(v reflect.Value, path string, visit fieldVisitor) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+
+			key, squash := fieldKey(field)
+			if key == "-" {
+				continue
+			}
+			fieldPath := joinPath(path, key)
+			if squash {
+				fieldPath = path
+			} else {
+				visit(fieldPath, field, v.Field(i))
+			}
+			walkFields(v.Field(i), fieldPath, visit)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			walkFields(v.Index(i), fmt.Sprintf("%s[%d]", path, i), visit)
+		}
+	case reflect.Map:
+		for _, key := range sortedMapKeys(v) {
+			walkFields(v.MapIndex(key), joinPath(path, fmt.Sprint(key.Interface())), visit)
+		}
+	}
+}
+
+// leafVisitor is called for every field of a struct type that holds a single configuration value
+type leafVisitor func(path string, field reflect.StructField, value reflect.Value)
+
+// walkLeaves calls visit for every field reachable from struct type t through
+// nested structs that has a fixed key path: scalars and lists of scalars, but
+// not maps or lists of structs, whose entries have no fixed path. v holds the
+// current values if it is valid. Self-referential types are followed once, so
+// that a field of the type being walked is not descended into again
+func walkLeaves(t reflect.Type, v reflect.Value, path string, visit leafVisitor) {
+	walkLeavesOnce(t, v, path, visit, map[reflect.Type]bool{})
+}
+
+// walkLeavesOnce is walkLeaves skipping the struct types in onPath, which are
+// being walked by its callers
+func walkLeavesOnce(t reflect.Type, v reflect.Value, path string, visit leafVisitor, onPath map[reflect.Type]bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		if v.IsValid() {
+			v = v.Elem()
+		}
+	}
+	if t.Kind() != reflect.Struct || onPath[t] {
+		return
+	}
+	onPath[t] = true
+	defer delete(onPath, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		key, squash := fieldKey(field)
+		if key == "-" {
+			continue
+		}
+
+		var fv reflect.Value
+		if v.IsValid() {
+			fv = v.Field(i)
+		}
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		switch {
+		case squash:
+			walkLeavesOnce(field.Type, fv, path, visit, onPath)
+		case ft.Kind() == reflect.Struct:
+			walkLeavesOnce(field.Type, fv, joinPath(path, key), visit, onPath)
+		case ft.Kind() == reflect.Map, (ft.Kind() == reflect.Slice || ft.Kind() == reflect.Array) && isStructType(ft.Elem()):
+			// Entries of maps and lists of structs have no fixed key path
+		default:
+			visit(joinPath(path, key), field, fv)
+		}
+	}
+}
+
+// isStructType reports whether t is a struct or a pointer to one
+func isStructType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// sortedMapKeys returns the keys of a map sorted by their string form, for a stable order
+func sortedMapKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+	})
+	return keys
+}
+
+// sortedKeys returns the keys of a map in lexical order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// fieldKey returns the configuration key of a struct field from its mapstructure
+// tag, and whether the field is squashed into its parent
+func fieldKey(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("mapstructure")
+	name, opts, _ := strings.Cut(tag, ",")
+	// Like mapstructure, embedded structs are only squashed when the tag asks for it
+	squash := strings.Contains(","+opts+",", ",squash,")
+	if name == "" {
+		// Viper matches keys case-insensitively and reports them in lower case
+		name = strings.ToLower(field.Name)
+	}
+	return name, squash
+}
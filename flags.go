@@ -0,0 +1,149 @@
+package easycfg
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// configFlag is the flag.Value and pflag.Value of a configuration key. It
+// keeps the text it is set to, which is decoded like values of the file
+type configFlag struct {
+	path   string
+	value  string
+	isBool bool
+	isList bool
+}
+
+// String returns the text the flag is set to
+func (f *configFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.value
+}
+
+// Set records the text of the flag, joining repeated list flags with commas
+func (f *configFlag) Set(value string) error {
+	if f.isList && f.value != "" {
+		value = f.value + "," + value
+	}
+	f.value = value
+	return nil
+}
+
+// Type returns the type name pflag shows in usage text
+func (f *configFlag) Type() string {
+	switch {
+	case f.isBool:
+		return "bool"
+	case f.isList:
+		return "strings"
+	default:
+		return "string"
+	}
+}
+
+// IsBoolFlag lets boolean flags be given without a value, such as -debug
+func (f *configFlag) IsBoolFlag() bool {
+	return f.isBool
+}
+
+// RegisterFlags defines a flag on fs for every field of configStruct that
+// holds a single value, named after its dotted key path, such as
+// -general.server.port=9999. Lists of scalars take comma-separated values or
+// repeated flags. Usage text comes from the doc tag of the field, and the
+// values configStruct holds are shown as defaults. Pass fs to LoadConfig with
+// WithFlags to apply the flags that are set
+func RegisterFlags(fs *flag.FlagSet, configStruct interface{}) {
+	walkConfigFlags(configStruct, func(f *configFlag, usage, defValue string) {
+		fs.Var(f, f.path, usage)
+		fs.Lookup(f.path).DefValue = defValue
+	})
+}
+
+// RegisterPFlags is RegisterFlags for a pflag.FlagSet, as used by cobra,
+// with flags such as --general.server.port=9999
+func RegisterPFlags(fs *pflag.FlagSet, configStruct interface{}) {
+	walkConfigFlags(configStruct, func(f *configFlag, usage, defValue string) {
+		flag := fs.VarPF(f, f.path, "", usage)
+		flag.DefValue = defValue
+		if f.isBool {
+			flag.NoOptDefVal = "true"
+		}
+	})
+}
+
+// WithFlags makes LoadConfig and WatchConfig apply the flags of fs that were
+// registered with RegisterFlags and set on the command line. Flags take
+// precedence over every other source. fs must be parsed before LoadConfig is called
+func WithFlags(fs *flag.FlagSet) Option {
+	return func(o *loadOptions) {
+		fs.Visit(func(f *flag.Flag) {
+			o.addFlag(f.Value)
+		})
+	}
+}
+
+// WithPFlags is WithFlags for a pflag.FlagSet registered with RegisterPFlags
+func WithPFlags(fs *pflag.FlagSet) Option {
+	return func(o *loadOptions) {
+		fs.Visit(func(f *pflag.Flag) {
+			o.addFlag(f.Value)
+		})
+	}
+}
+
+// addFlag records the value of a configuration flag, ignoring other flags
+func (o *loadOptions) addFlag(value interface{}) {
+	f, ok := value.(*configFlag)
+	if !ok {
+		return
+	}
+	if o.flags == nil {
+		o.flags = map[string]string{}
+	}
+	o.flags[f.path] = f.value
+}
+
+// walkConfigFlags calls define with a flag for every field of configStruct that holds a single value
+func walkConfigFlags(configStruct interface{}, define func(f *configFlag, usage, defValue string)) {
+	walkLeaves(reflect.TypeOf(configStruct), reflect.ValueOf(configStruct), "", func(path string, field reflect.StructField, value reflect.Value) {
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		f := &configFlag{
+			path:   path,
+			isBool: ft.Kind() == reflect.Bool,
+			isList: ft.Kind() == reflect.Slice || ft.Kind() == reflect.Array,
+		}
+
+		usage := field.Tag.Get("doc")
+		if usage == "" {
+			usage = fmt.Sprintf("Overrides the %s configuration key", path)
+		}
+		define(f, usage, flagDefault(value))
+	})
+}
+
+// flagDefault returns the text shown as the default of a flag for the current value of a field
+func flagDefault(value reflect.Value) string {
+	for value.IsValid() && value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	if !value.IsValid() || value.IsZero() {
+		return ""
+	}
+	if value.Kind() == reflect.Slice || value.Kind() == reflect.Array {
+		items := make([]string, value.Len())
+		for i := range items {
+			items[i] = fmt.Sprint(value.Index(i).Interface())
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(value.Interface())
+}
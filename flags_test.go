@@ -0,0 +1,134 @@
+package easycfg
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+// flagConfig is a configuration struct with flags for every field
+type flagConfig struct {
+	General struct {
+		Server struct {
+			Addr  string   `mapstructure:"addr" doc:"Listen address"`
+			Debug bool     `mapstructure:"debug"`
+			Hosts []string `mapstructure:"hosts"`
+		} `mapstructure:"server"`
+	} `mapstructure:"general"`
+	Retries int               `mapstructure:"retries"`
+	Labels  map[string]string `mapstructure:"labels"`
+}
+
+func TestRegisterFlags(t *testing.T) {
+	yamlContent := []byte("general:\n  server:\n    addr: :8080\nretries: 3\n")
+	t.Setenv("GENERAL_SERVER_ADDR", ":7070")
+
+	defaults := &flagConfig{}
+	defaults.General.Server.Addr = ":8080"
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	RegisterFlags(fs, defaults)
+
+	f := fs.Lookup("general.server.addr")
+	if f == nil || f.Usage != "Listen address" || f.DefValue != ":8080" {
+		t.Fatalf("Unexpected flag: %+v", f)
+	}
+	if fs.Lookup("labels") != nil {
+		t.Errorf("Unexpected flag for a map field")
+	}
+
+	args := []string{"--general.server.addr=:9999", "-general.server.debug", "-general.server.hosts=a,b", "-general.server.hosts=c"}
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
+	}
+
+	// Flags take precedence over the environment and the file, and unset flags keep the file value
+	cfg := &flagConfig{}
+	if err := LoadConfig("", cfg, WithConfigData(yamlContent, "yaml"), WithEnv(), WithFlags(fs)); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.General.Server.Addr != ":9999" || !cfg.General.Server.Debug || cfg.Retries != 3 {
+		t.Errorf("Unexpected configuration: %+v", cfg)
+	}
+	if !reflect.DeepEqual(cfg.General.Server.Hosts, []string{"a", "b", "c"}) {
+		t.Errorf("cfg.General.Server.Hosts = %v, expected [a b c]", cfg.General.Server.Hosts)
+	}
+
+	// Usage text shows the doc tag and the default
+	var usage strings.Builder
+	fs.SetOutput(&usage)
+	fs.PrintDefaults()
+	if !strings.Contains(usage.String(), "Listen address (default :8080)") {
+		t.Errorf("Unexpected usage text:\n%s", usage.String())
+	}
+}
+
+func TestRegisterPFlags(t *testing.T) {
+	yamlContent := []byte("retries: 3\n")
+
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	RegisterPFlags(fs, &flagConfig{})
+	if err := fs.Parse([]string{"--retries=5", "--general.server.debug"}); err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
+	}
+
+	cfg := &flagConfig{}
+	if err := LoadConfig("", cfg, WithConfigData(yamlContent, "yaml"), WithPFlags(fs)); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Retries != 5 || !cfg.General.Server.Debug {
+		t.Errorf("Unexpected configuration: %+v", cfg)
+	}
+}
+
+// FlagBase is a struct embedded into flag configurations
+type FlagBase struct {
+	Port int `mapstructure:"port"`
+}
+
+func TestRegisterFlagsEmbedded(t *testing.T) {
+	type embeddedConfig struct {
+		FlagBase
+		Name string `mapstructure:"name"`
+	}
+	type squashedConfig struct {
+		FlagBase `mapstructure:",squash"`
+		Name     string `mapstructure:"name"`
+	}
+
+	// Embedded structs are nested under their type name unless squashed, as mapstructure decodes them
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	RegisterFlags(fs, &embeddedConfig{})
+	if fs.Lookup("flagbase.port") == nil || fs.Lookup("port") != nil {
+		t.Errorf("Expected flag flagbase.port for an embedded struct")
+	}
+	if err := fs.Parse([]string{"-flagbase.port=9090"}); err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
+	}
+	embedded := &embeddedConfig{}
+	if err := LoadConfig("", embedded, WithConfigData([]byte("name: app\n"), "yaml"), WithFlags(fs), WithStrict()); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if embedded.Port != 9090 {
+		t.Errorf("embedded.Port = %d, expected 9090", embedded.Port)
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	RegisterFlags(fs, &squashedConfig{})
+	if fs.Lookup("port") == nil || fs.Lookup("flagbase.port") != nil {
+		t.Errorf("Expected flag port for a squashed struct")
+	}
+
+	// Self-referential types are registered once
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	RegisterFlags(fs, &recursiveNode{Next: &recursiveNode{}})
+	pfs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	RegisterPFlags(pfs, &recursiveNode{})
+	if fs.Lookup("name") == nil || pfs.Lookup("name") == nil || fs.Lookup("next.name") != nil {
+		t.Errorf("Unexpected flags for a recursive struct")
+	}
+}
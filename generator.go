@@ -1,28 +1,287 @@
 package easycfg
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"unicode"
+)
+
+// modulePath is the import path generated code uses to reference easycfg
+const modulePath = "github.com/chiayu0816/easycfg"
+
+// GenerateOption configures optional behavior of YamlToStruct
+type GenerateOption func(*generateOptions)
 
-	"github.com/go-yaml/yaml"
+// Language selects the language of the code YamlToStruct generates
+type Language string
+
+const (
+	LanguageGo         Language = "go"    // Go structs, written to <name>.go
+	LanguageTypeScript Language = "ts"    // TypeScript interfaces, written to <name>.ts
+	LanguageProto      Language = "proto" // Protocol Buffers messages, written to <name>.proto
 )
 
+// generateOptions holds the settings collected from GenerateOption values
+type generateOptions struct {
+	compareMethods bool
+	keyConstants   bool
+	getters        bool
+	loader         bool
+	embedDefault   bool
+	detectSecrets  bool
+	mappingFile    string
+	templatePath   string
+	scaffold       bool
+	splitFiles     bool
+	decimalFloats  bool
+	docsEnvPrefix  string
+	language       Language
+	sampleStruct   interface{}
+	sampleGoFile   string
+}
+
+// WithCompareMethods makes YamlToStruct emit DeepCopy, Equal and Diff methods
+// for the root struct and every nested struct
+func WithCompareMethods() GenerateOption {
+	return func(o *generateOptions) {
+		o.compareMethods = true
+	}
+}
+
+// WithKeyConstants makes YamlToStruct emit a constant holding the dotted key
+// path of every leaf value, such as KeyGeneralServerPort = "general.server.port"
+func WithKeyConstants() GenerateOption {
+	return func(o *generateOptions) {
+		o.keyConstants = true
+	}
+}
+
+// WithGetters makes YamlToStruct emit nil-safe Get methods for every field,
+// so nested values can be read through chains of possibly nil pointers
+func WithGetters() GenerateOption {
+	return func(o *generateOptions) {
+		o.getters = true
+	}
+}
+
+// WithLoader makes YamlToStruct emit typed Load<Struct> and Watch<Struct>
+// functions wrapping LoadConfig and WatchConfig for the root struct
+func WithLoader() GenerateOption {
+	return func(o *generateOptions) {
+		o.loader = true
+	}
+}
+
+// WithEmbeddedDefault makes YamlToStruct copy the source YAML next to the
+// generated file and embed it with go:embed, so the generated Load<Struct>
+// and Watch<Struct> functions fall back to it when no path is given.
+// It implies WithLoader
+func WithEmbeddedDefault() GenerateOption {
+	return func(o *generateOptions) {
+		o.loader = true
+		o.embedDefault = true
+	}
+}
+
+// WithSecretDetection makes YamlToStruct type string values whose key looks
+// secret (password, token, secret, key, dsn) as easycfg.Secret. Keys marked
+// with a "# easycfg:secret" comment are typed as easycfg.Secret regardless,
+// and "# easycfg:secret=false" excludes a key from detection
+func WithSecretDetection() GenerateOption {
+	return func(o *generateOptions) {
+		o.detectSecrets = true
+	}
+}
+
+// WithDecimalFloats makes YamlToStruct type floating point values as
+// easycfg.Decimal, which keeps their decimal text, instead of float64.
+// A "# easycfg:type=decimal" comment does the same for a single key
+func WithDecimalFloats() GenerateOption {
+	return func(o *generateOptions) {
+		o.decimalFloats = true
+	}
+}
+
+// WithMappingFile makes YamlToStruct read easycfg directives, such as enum
+// and secret, from a YAML file mapping dotted key paths to directives:
+//
+//	logger.level:
+//	  enum: [debug, info, warn, error]
+//	redis.password:
+//	  secret: true
+//
+// Directives in comments of the source YAML take precedence over the file
+func WithMappingFile(path string) GenerateOption {
+	return func(o *generateOptions) {
+		o.mappingFile = path
+	}
+}
+
+// WithTemplate makes YamlToStruct render its output with the text/template
+// file, or every *.tmpl file in the directory, at path. The templates are
+// parsed on top of the default template of the language selected with
+// WithLanguage, templates/go.tmpl, templates/ts.tmpl or templates/proto.tmpl,
+// and receive a *Model; execution starts at the template named "file", so a
+// custom template can replace the whole output by defining "file" or adjust it
+// by redefining any of the templates "file" calls. For Go these are "header",
+// "imports", "keys", "struct", "getters", "compare", "enum" and "loader"
+func WithTemplate(path string) GenerateOption {
+	return func(o *generateOptions) {
+		o.templatePath = path
+	}
+}
+
+// WithScaffold makes YamlToStruct write the generated code to <name>_gen.go,
+// which is overwritten on every run, and create <name>.go once as a place for
+// hand-written code next to the generated types. An existing <name>.go is only
+// replaced if it was itself generated by easycfg
+func WithScaffold() GenerateOption {
+	return func(o *generateOptions) {
+		o.scaffold = true
+	}
+}
+
+// WithSplitFiles makes YamlToStruct write the struct tree of every top-level
+// section to its own file, such as general_gen.go and redis_gen.go, keeping
+// the root struct, key constants and loader in the main file. Section files
+// left over from removed sections are deleted on the next run
+func WithSplitFiles() GenerateOption {
+	return func(o *generateOptions) {
+		o.splitFiles = true
+	}
+}
+
+// WithLanguage makes YamlToStruct generate code in the given language. With
+// LanguageTypeScript it writes <name>.ts, declaring an interface for every
+// struct and a string union type for every enum, with the YAML keys as
+// property names. With LanguageProto it writes a proto3 <name>.proto,
+// declaring a message for every struct and an enum for every enum directive,
+// and records the field numbers in <name>.proto.lock so that regeneration
+// never renumbers fields and removed fields are reserved. Options that only
+// apply to Go code are ignored
+func WithLanguage(lang Language) GenerateOption {
+	return func(o *generateOptions) {
+		o.language = lang
+	}
+}
+
 // YamlToStruct converts YAML file to Go struct and generates Go file
-func YamlToStruct(yamlFilePath, outputDir, packageName string) error {
-	// Read YAML file
-	yamlData, err := os.ReadFile(yamlFilePath)
+func YamlToStruct(yamlFilePath, outputDir, packageName string, opts ...GenerateOption) error {
+	options := newGenerateOptions(opts)
+	out, err := generateOutput(yamlFilePath, outputDir, packageName, options)
 	if err != nil {
-		return fmt.Errorf("failed to read YAML file: %v", err)
+		return err
+	}
+
+	// Ensure output directory exists
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %v", err)
 	}
 
-	// Parse YAML data
-	var yamlMap map[interface{}]interface{}
-	if err := yaml.Unmarshal(yamlData, &yamlMap); err != nil {
-		return fmt.Errorf("failed to parse YAML data: %v", err)
+	// Write hand-written code scaffold
+	if out.scaffoldPath != "" {
+		if err := writeScaffold(out.scaffoldPath, out.structName, packageName); err != nil {
+			return err
+		}
+	}
+
+	// Write generated files
+	for _, file := range out.files {
+		if err := writeGeneratedFile(file); err != nil {
+			return err
+		}
+	}
+
+	// Remove files of sections that no longer exist
+	for _, path := range out.stale {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove stale generated file: %v", err)
+		}
+	}
+
+	switch options.language {
+	case LanguageTypeScript:
+		fmt.Printf("Successfully generated TypeScript file: %s\n", out.files[0].path)
+		return nil
+	case LanguageProto:
+		fmt.Printf("Successfully generated Protocol Buffers file: %s\n", out.files[0].path)
+		return nil
+	}
+	fmt.Printf("Successfully generated Go struct file: %s\n", out.files[0].path)
+	return nil
+}
+
+// CheckGenerated reports whether the files YamlToStruct would write for the
+// same arguments are up to date, without writing anything. It returns nil if
+// they are, and an *OutdatedError holding a unified diff of every missing or
+// stale file otherwise
+func CheckGenerated(yamlFilePath, outputDir, packageName string, opts ...GenerateOption) error {
+	out, err := generateOutput(yamlFilePath, outputDir, packageName, newGenerateOptions(opts))
+	if err != nil {
+		return err
+	}
+
+	outdated := &OutdatedError{}
+	for _, file := range out.files {
+		existing, err := os.ReadFile(file.path)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read generated file: %v", err)
+		}
+		if bytes.Equal(existing, file.content) && err == nil {
+			continue
+		}
+		outdated.Paths = append(outdated.Paths, file.path)
+		outdated.Diff += unifiedDiff(file.path, string(existing), string(file.content))
+	}
+	for _, path := range out.stale {
+		existing, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read generated file: %v", err)
+		}
+		outdated.Paths = append(outdated.Paths, path)
+		outdated.Diff += unifiedDiff(path, string(existing), "")
+	}
+
+	if len(outdated.Paths) > 0 {
+		return outdated
+	}
+	return nil
+}
+
+// generatedFile is a file YamlToStruct overwrites on every run
+type generatedFile struct {
+	path    string
+	content []byte
+	code    bool // Code is only overwritten if it was generated by easycfg
+}
+
+// generatedOutput holds everything YamlToStruct writes for a YAML file
+type generatedOutput struct {
+	structName   string
+	scaffoldPath string // File for hand-written code, empty unless scaffolding
+	files        []generatedFile
+	stale        []string // Generated files from the same source that are no longer written
+}
+
+// newGenerateOptions applies opts to the default generate options
+func newGenerateOptions(opts []GenerateOption) *generateOptions {
+	options := &generateOptions{language: LanguageGo}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// generateOutput renders the files for a YAML file in memory
+func generateOutput(yamlFilePath, outputDir, packageName string, options *generateOptions) (*generatedOutput, error) {
+	// Read YAML file
+	yamlData, err := os.ReadFile(yamlFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read YAML file: %v", err)
 	}
 
 	// Get file name (without extension) as struct name
@@ -30,111 +289,239 @@ func YamlToStruct(yamlFilePath, outputDir, packageName string) error {
 	structName := strings.TrimSuffix(baseName, filepath.Ext(baseName))
 	structName = toCamelCase(structName)
 
-	// Generate Go struct code
-	var nestedStructs []string
-	mainStruct := generateMainStruct(yamlMap, structName, &nestedStructs)
+	out := &generatedOutput{structName: structName}
+	fileName := strings.ToLower(structName)
 
-	// Combine all struct codes
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("// This file is automatically generated by easycfg, do not modify manually\n"))
-	sb.WriteString(fmt.Sprintf("package %s\n\n", packageName))
-	sb.WriteString(mainStruct)
+	// Other languages are written to a single file, options for Go files do not apply
+	switch options.language {
+	case LanguageGo:
+	case LanguageTypeScript, LanguageProto:
+		codePath := filepath.Join(outputDir, fileName+"."+string(options.language))
+		if out.files, err = generateCode(yamlData, baseName, structName, packageName, codePath, options); err != nil {
+			return nil, err
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unsupported language %q, must be one of: %s, %s, %s", options.language, LanguageGo, LanguageTypeScript, LanguageProto)
+	}
 
-	for _, nestedStruct := range nestedStructs {
-		sb.WriteString("\n" + nestedStruct)
+	codePath := filepath.Join(outputDir, fileName+".go")
+	if options.scaffold {
+		out.scaffoldPath = codePath
+		codePath = filepath.Join(outputDir, fileName+"_gen.go")
 	}
 
-	// Ensure output directory exists
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %v", err)
+	// Generate Go struct code
+	out.files, err = generateCode(yamlData, baseName, structName, packageName, codePath, options)
+	if err != nil {
+		return nil, err
+	}
+	if out.stale, err = staleFiles(outputDir, out.files); err != nil {
+		return nil, err
 	}
 
-	// Write Go file
-	outputFilePath := filepath.Join(outputDir, strings.ToLower(structName)+".go")
-	if err := os.WriteFile(outputFilePath, []byte(sb.String()), 0644); err != nil {
-		return fmt.Errorf("failed to write Go file: %v", err)
+	// go:embed can only reference files inside the package directory
+	if options.embedDefault {
+		embedPath := filepath.Join(outputDir, baseName)
+		same, err := samePath(yamlFilePath, embedPath)
+		if err != nil {
+			return nil, err
+		}
+		if !same {
+			out.files = append(out.files, generatedFile{path: embedPath, content: yamlData})
+		}
 	}
 
-	fmt.Printf("Successfully generated Go struct file: %s\n", outputFilePath)
-	return nil
+	return out, nil
 }
 
-// generateMainStruct generates the main struct
-func generateMainStruct(yamlMap map[interface{}]interface{}, structName string, nestedStructs *[]string) string {
-	var sb strings.Builder
+// generateCode parses YAML data and renders it with the output template into the
+// file at codePath and, when splitting, one file per top-level section next to it.
+// Protocol Buffers output is followed by its field number lock file
+func generateCode(yamlData []byte, yamlFileName, structName, packageName, codePath string, options *generateOptions) ([]generatedFile, error) {
+	mapping, err := loadMappingFile(options.mappingFile)
+	if err != nil {
+		return nil, err
+	}
 
-	// Generate main struct
-	sb.WriteString(fmt.Sprintf("// %s configuration struct\n", structName))
-	sb.WriteString(fmt.Sprintf("type %s struct {\n", structName))
+	model, err := buildModel(yamlData, yamlFileName, structName, packageName, options, mapping)
+	if err != nil {
+		return nil, err
+	}
 
-	// Iterate through YAML map and generate struct fields
-	for key, value := range yamlMap {
-		fieldName := toCamelCase(key.(string))
-		fieldType, nestedStruct := getFieldTypeAndNestedStruct(value, fieldName, nestedStructs)
+	tmpl, err := loadTemplate(options.language, options.templatePath)
+	if err != nil {
+		return nil, err
+	}
 
-		// Add field
-		sb.WriteString(fmt.Sprintf("\t%s %s `yaml:\"%s\" mapstructure:\"%s\"`\n",
-			fieldName, fieldType, key, key))
+	var lockFile *generatedFile
+	if options.language == LanguageProto {
+		if lockFile, err = applyProtoLock(model, codePath+".lock"); err != nil {
+			return nil, err
+		}
+	}
+
+	models := []*Model{model}
+	if options.splitFiles && options.language == LanguageGo {
+		models = splitModel(model)
+	}
+
+	var files []generatedFile
+	names := map[string]bool{filepath.Base(codePath): true}
+	for _, m := range models {
+		path := codePath
+		if m.Section != "" {
+			path = filepath.Join(filepath.Dir(codePath), sectionFileName(m.Section, names))
+		}
 
-		// If there is a nested struct, add it to the list
-		if nestedStruct != "" {
-			*nestedStructs = append(*nestedStructs, nestedStruct)
+		var buf bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&buf, "file", m); err != nil {
+			return nil, fmt.Errorf("failed to execute template: %v", err)
 		}
+		files = append(files, generatedFile{path: path, content: buf.Bytes(), code: true})
 	}
+	if lockFile != nil {
+		files = append(files, *lockFile)
+	}
+	return files, nil
+}
 
-	sb.WriteString("}\n")
-	return sb.String()
+// sectionFileName returns an unused file name for the code of a top-level section
+func sectionFileName(section string, names map[string]bool) string {
+	base := strings.ToLower(strings.Trim(nonAlphanumericRegexp.ReplaceAllString(section, "_"), "_"))
+	name := base + "_gen.go"
+	for i := 2; names[name]; i++ {
+		name = fmt.Sprintf("%s%d_gen.go", base, i)
+	}
+	names[name] = true
+	return name
 }
 
-// getFieldTypeAndNestedStruct gets field type and nested struct
-func getFieldTypeAndNestedStruct(value interface{}, fieldName string, nestedStructs *[]string) (string, string) {
-	switch v := value.(type) {
-	case map[interface{}]interface{}:
-		// Nested struct
-		structName := fieldName
-		var sb strings.Builder
-		sb.WriteString(fmt.Sprintf("// %s nested struct\n", structName))
-		sb.WriteString(fmt.Sprintf("type %s struct {\n", structName))
+// staleFiles returns the *_gen.go files in outputDir that carry the same generated
+// code marker as the files being written, and thus come from the same source, but
+// are no longer part of the output, such as the files of removed sections
+func staleFiles(outputDir string, files []generatedFile) ([]string, error) {
+	marker := generatedMarker(files[0].content)
+	if marker == "" {
+		return nil, nil
+	}
 
-		for key, val := range v {
-			subFieldName := toCamelCase(key.(string))
-			subFieldType, subNestedStruct := getFieldTypeAndNestedStruct(val, structName+subFieldName, nestedStructs)
+	current := make(map[string]bool, len(files))
+	for _, file := range files {
+		current[filepath.Clean(file.path)] = true
+	}
 
-			sb.WriteString(fmt.Sprintf("\t%s %s `yaml:\"%s\" mapstructure:\"%s\"`\n",
-				subFieldName, subFieldType, key, key))
+	paths, err := filepath.Glob(filepath.Join(outputDir, "*_gen.go"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list generated files: %v", err)
+	}
 
-			if subNestedStruct != "" {
-				*nestedStructs = append(*nestedStructs, subNestedStruct)
-			}
+	var stale []string
+	for _, path := range paths {
+		if current[filepath.Clean(path)] {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read generated file: %v", err)
+		}
+		if generatedMarker(content) == marker {
+			stale = append(stale, path)
 		}
+	}
+	return stale, nil
+}
 
-		sb.WriteString("}\n")
-		return structName, sb.String()
-	case []interface{}:
-		// Array/slice
-		if len(v) > 0 {
-			elemType, _ := getFieldTypeAndNestedStruct(v[0], fieldName+"Elem", nestedStructs)
-			return "[]" + elemType, ""
+// samePath reports whether two paths refer to the same file location
+func samePath(a, b string) (bool, error) {
+	aAbs, err := filepath.Abs(a)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve YAML file path: %v", err)
+	}
+	bAbs, err := filepath.Abs(b)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve embedded YAML path: %v", err)
+	}
+	return aAbs == bAbs, nil
+}
+
+// writeGeneratedFile writes a generated file, refusing to overwrite code
+// that was not generated by easycfg
+func writeGeneratedFile(file generatedFile) error {
+	if file.code {
+		existing, err := os.ReadFile(file.path)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read existing code file: %v", err)
 		}
-		return "[]interface{}", ""
-	case string:
-		return "string", ""
-	case int:
-		return "int", ""
-	case float64:
-		return "float64", ""
-	case bool:
-		return "bool", ""
-	default:
-		return "interface{}", ""
+		if err == nil && !isGeneratedCode(existing) {
+			return fmt.Errorf("refusing to overwrite %s: file was not generated by easycfg", file.path)
+		}
+	}
+
+	if err := os.WriteFile(file.path, file.content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", file.path, err)
+	}
+	return nil
+}
+
+// writeScaffold creates the file for hand-written code unless it already holds user code
+func writeScaffold(path, structName, packageName string) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read existing Go file: %v", err)
+	}
+
+	// A file generated before scaffolding was enabled would duplicate the generated
+	// types, so it is replaced; anything else is user code and kept as is
+	if err == nil && !isGeneratedCode(existing) {
+		return nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("package %s\n\n", packageName))
+	sb.WriteString(fmt.Sprintf("// This file holds hand-written code for the %s configuration. The generated\n", structName))
+	sb.WriteString(fmt.Sprintf("// types live in %s, which easycfg overwrites on every run;\n", strings.ToLower(structName)+"_gen.go"))
+	sb.WriteString("// easycfg creates this file once and never modifies it afterwards.\n")
+	if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write scaffold file: %v", err)
 	}
+	return nil
 }
 
+// generatedCodeRegexp matches the Go convention for marking generated files
+var generatedCodeRegexp = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// isGeneratedCode reports whether Go source carries a generated code marker
+// in the comments before its package clause
+func isGeneratedCode(content []byte) bool {
+	return generatedMarker(content) != ""
+}
+
+// generatedMarker returns the generated code marker line of Go source, or an
+// empty string if the comments before its package clause contain none
+func generatedMarker(content []byte) string {
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if generatedCodeRegexp.MatchString(line) || line == legacyGeneratedHeader {
+			return line
+		}
+		if line != "" && !strings.HasPrefix(line, "//") {
+			return ""
+		}
+	}
+	return ""
+}
+
+// legacyGeneratedHeader is the header of files generated by earlier versions of easycfg
+const legacyGeneratedHeader = "// This file is automatically generated by easycfg, do not modify manually"
+
+// nonAlphanumericRegexp matches runs of characters that cannot appear in Go names
+var nonAlphanumericRegexp = regexp.MustCompile("[^a-zA-Z0-9]+")
+
 // toCamelCase converts snake_case to CamelCase
 func toCamelCase(s string) string {
 	// Handle special characters
-	reg := regexp.MustCompile("[^a-zA-Z0-9]+")
-	s = reg.ReplaceAllString(s, "_")
+	s = nonAlphanumericRegexp.ReplaceAllString(s, "_")
 
 	// Split string
 	parts := strings.Split(s, "_")
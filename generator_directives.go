@@ -0,0 +1,232 @@
+package easycfg
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// secretType is the Go type generated for secret values
+const secretType = "easycfg.Secret"
+
+// decimalType is the Go type generated for decimal values
+const decimalType = "easycfg.Decimal"
+
+// directiveTypes maps the values of type directives to the Go types they select
+var directiveTypes = map[string]string{
+	"int":     "int",
+	"int8":    "int8",
+	"int16":   "int16",
+	"int32":   "int32",
+	"int64":   "int64",
+	"uint":    "uint",
+	"uint8":   "uint8",
+	"uint16":  "uint16",
+	"uint32":  "uint32",
+	"uint64":  "uint64",
+	"float32": "float32",
+	"float64": "float64",
+	"string":  "string",
+	"decimal": decimalType,
+}
+
+// directiveRegexp matches "easycfg:name" and "easycfg:name=value" directives in comments
+var directiveRegexp = regexp.MustCompile(`easycfg:([a-zA-Z_]+)(?:=(\S*))?`)
+
+// secretWords are key words that mark a value as secret
+var secretWords = map[string]bool{
+	"password": true,
+	"passwd":   true,
+	"token":    true,
+	"secret":   true,
+	"key":      true,
+	"dsn":      true,
+}
+
+// parseDirectives collects the easycfg directives from the comments of a key and its value
+func parseDirectives(key, value *yaml.Node) map[string]string {
+	comments := []string{key.HeadComment, key.LineComment, value.LineComment}
+
+	directives := map[string]string{}
+	for _, comment := range comments {
+		for _, m := range directiveRegexp.FindAllStringSubmatch(comment, -1) {
+			directives[m[1]] = m[2]
+		}
+	}
+	return directives
+}
+
+// directives returns the directives of a key, merging the mapping file with its comments
+func (b *structBuilder) directives(path string, key, value *yaml.Node) map[string]string {
+	directives := map[string]string{}
+	for name, v := range b.mapping[path] {
+		directives[name] = v
+	}
+	for name, v := range parseDirectives(key, value) {
+		directives[name] = v
+	}
+	return directives
+}
+
+// loadMappingFile reads the directives of a mapping file, keyed by dotted path
+func loadMappingFile(path string) (map[string]map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mapping file: %v", err)
+	}
+
+	var raw map[string]map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse mapping file: %v", err)
+	}
+
+	// Lists are joined with commas to match the comment directive syntax
+	mapping := make(map[string]map[string]string, len(raw))
+	for path, entries := range raw {
+		mapping[path] = make(map[string]string, len(entries))
+		for name, value := range entries {
+			if list, ok := value.([]interface{}); ok {
+				values := make([]string, len(list))
+				for i, v := range list {
+					values[i] = fmt.Sprint(v)
+				}
+				mapping[path][name] = strings.Join(values, ",")
+				continue
+			}
+			mapping[path][name] = fmt.Sprint(value)
+		}
+	}
+	return mapping, nil
+}
+
+// applyType overrides the inferred type of a number, or of the elements of a
+// list or map of numbers, with the type given by a type directive
+func (b *structBuilder) applyType(field *FieldDef) {
+	goType, ok := directiveTypes[field.Directives["type"]]
+	if !ok {
+		return
+	}
+
+	t := elemType(field.Type)
+	if t.Kind == KindScalar && isNumericType(t.Name) {
+		t.Name = goType
+	}
+}
+
+// elemType returns the element type of a slice or map, or t itself for other types
+func elemType(t *TypeRef) *TypeRef {
+	if t.Kind == KindSlice || t.Kind == KindMap {
+		return t.Elem
+	}
+	return t
+}
+
+// isMapDirective reports whether a map directive marks a mapping as having dynamic keys
+func isMapDirective(directives map[string]string) bool {
+	value, ok := directives["map"]
+	return ok && value != "false"
+}
+
+// isNumericType reports whether a scalar type name is a Go number type or easycfg.Decimal
+func isNumericType(name string) bool {
+	return strings.HasPrefix(name, "int") || strings.HasPrefix(name, "uint") || strings.HasPrefix(name, "float") || name == decimalType
+}
+
+// applySecret types the field as easycfg.Secret if it is marked or detected as secret
+func (b *structBuilder) applySecret(field *FieldDef) {
+	value, marked := field.Directives["secret"]
+	isSecret := marked && value != "false"
+	if !marked && b.options.detectSecrets {
+		isSecret = isSecretKey(field.Key)
+	}
+	if !isSecret {
+		return
+	}
+
+	// Secrets apply to strings and to the elements of string lists and maps
+	t := elemType(field.Type)
+	if t.Kind == KindScalar && t.Name == "string" {
+		t.Name = secretType
+	}
+}
+
+// applyEnum types the field as a generated enum if it has an enum directive
+func (b *structBuilder) applyEnum(field *FieldDef, typeName string) {
+	value, ok := field.Directives["enum"]
+	if !ok {
+		return
+	}
+
+	// Enums apply to strings and to the elements of string lists and maps
+	t := elemType(field.Type)
+	if t.Kind != KindScalar || t.Name != "string" {
+		return
+	}
+
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	if len(values) == 0 {
+		return
+	}
+
+	ed := &EnumDef{Name: b.uniqueName(typeName), Path: field.Path}
+	used := map[string]bool{}
+	for i, v := range values {
+		// Constants are named after the type and the CamelCase value
+		constName := ed.Name + toCamelCase(v)
+		if constName == ed.Name {
+			constName = fmt.Sprintf("%sValue%d", ed.Name, i+1)
+		}
+		unique := constName
+		for n := 2; used[unique]; n++ {
+			unique = fmt.Sprintf("%s%d", constName, n)
+		}
+		used[unique] = true
+		ed.Values = append(ed.Values, &EnumValue{Const: unique, Value: v})
+	}
+	b.enums = append(b.enums, ed)
+	t.Name = ed.Name
+}
+
+// isSecretKey reports whether any word of a key looks like it names a secret
+func isSecretKey(key string) bool {
+	for _, word := range splitWords(key) {
+		if secretWords[strings.ToLower(word)] {
+			return true
+		}
+	}
+	return false
+}
+
+// splitWords splits a key into words at separators and camelCase boundaries
+func splitWords(key string) []string {
+	var words []string
+	var current []rune
+	runes := []rune(key)
+	for i, r := range runes {
+		isSep := !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+		isBoundary := i > 0 && r >= 'A' && r <= 'Z' && runes[i-1] >= 'a' && runes[i-1] <= 'z'
+		if (isSep || isBoundary) && len(current) > 0 {
+			words = append(words, string(current))
+			current = nil
+		}
+		if !isSep {
+			current = append(current, r)
+		}
+	}
+	if len(current) > 0 {
+		words = append(words, string(current))
+	}
+	return words
+}
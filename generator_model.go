@@ -0,0 +1,456 @@
+package easycfg
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// buildModel parses YAML data into the model rendered by the output template
+func buildModel(yamlData []byte, yamlFileName, structName, packageName string, options *generateOptions, mapping map[string]map[string]string) (*Model, error) {
+	// Parse YAML data
+	var doc yaml.Node
+	if err := yaml.Unmarshal(yamlData, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML data: %v", err)
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		root = resolveAlias(doc.Content[0])
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("failed to parse YAML data: top level must be a mapping")
+	}
+
+	b := &structBuilder{names: map[string]bool{}, options: options, mapping: mapping}
+	b.buildStruct(root, structName, "", "")
+
+	model := &Model{
+		Package: packageName,
+		Source:  yamlFileName,
+		Root:    b.structs[0],
+		Structs: b.structs,
+		Enums:   b.enums,
+		Options: ModelOptions{
+			CompareMethods: options.compareMethods,
+			KeyConstants:   options.keyConstants,
+			Getters:        options.getters,
+			Loader:         options.loader,
+			EmbedDefault:   options.embedDefault,
+		},
+	}
+	model.Root.IsRoot = true
+	model.Keys = modelKeys(model)
+	model.Imports = modelImports(model)
+	return model, nil
+}
+
+// splitModel splits a model into the model of the main file, holding the root
+// struct, key constants and loader, and one model per top-level section holding
+// the structs and enums declared under it
+func splitModel(model *Model) []*Model {
+	mainModel := *model
+	mainModel.Structs = []*StructDef{model.Root}
+	mainModel.Enums = nil
+	models := []*Model{&mainModel}
+
+	// Sections are the top-level keys holding a mapping or a list or map of mappings
+	for _, f := range model.Root.Fields {
+		t := f.Type
+		for t.Kind == KindSlice || t.Kind == KindMap {
+			t = t.Elem
+		}
+		if t.Kind != KindStruct {
+			continue
+		}
+
+		section := &Model{
+			Package: model.Package,
+			Source:  model.Source,
+			Section: f.Key,
+			Root:    model.Root,
+			Options: model.Options,
+		}
+		section.Options.KeyConstants = false
+		section.Options.Loader = false
+		section.Options.EmbedDefault = false
+		for _, sd := range model.Structs[1:] {
+			if inSection(sd.Path, f.Path) {
+				section.Structs = append(section.Structs, sd)
+			}
+		}
+		models = append(models, section)
+	}
+
+	// Enums of top-level scalars stay in the main file
+	for _, e := range model.Enums {
+		target := &mainModel
+		for _, m := range models[1:] {
+			if inSection(e.Path, m.Section) {
+				target = m
+			}
+		}
+		target.Enums = append(target.Enums, e)
+	}
+
+	for _, m := range models {
+		m.Imports = modelImports(m)
+	}
+	return models
+}
+
+// inSection reports whether a dotted key path lies under the top-level key section
+func inSection(path, section string) bool {
+	return path == section || strings.HasPrefix(path, section+".")
+}
+
+// structBuilder collects type definitions while walking a YAML tree
+type structBuilder struct {
+	structs []*StructDef
+	enums   []*EnumDef
+	names   map[string]bool
+	options *generateOptions
+	mapping map[string]map[string]string
+}
+
+// buildStruct generates a struct definition for a YAML mapping and returns its name
+func (b *structBuilder) buildStruct(node *yaml.Node, structName, path, comment string) string {
+	structName = b.uniqueName(structName)
+	sd := &StructDef{Name: structName, Path: path, Comment: comment}
+	b.structs = append(b.structs, sd)
+
+	// Nested struct names are prefixed with their parent, except for the root
+	prefix := structName
+	if path == "" {
+		prefix = ""
+	}
+
+	// Iterate through YAML mapping and generate struct fields
+	for _, kv := range mappingPairs(node) {
+		key, value := kv[0], resolveAlias(kv[1])
+		fieldName := toCamelCase(key.Value)
+		fieldPath := joinPath(path, key.Value)
+		comment := nodeComment(key, value)
+		field := &FieldDef{
+			Name:       fieldName,
+			Key:        key.Value,
+			Path:       fieldPath,
+			Tag:        fieldTag(key.Value, comment),
+			Comment:    comment,
+			Sample:     nodeSample(value),
+			Directives: b.directives(fieldPath, key, value),
+		}
+		if value.Kind == yaml.MappingNode && isMapDirective(field.Directives) {
+			field.Type = b.inferMapType(value, prefix+fieldName, fieldPath, comment)
+		} else {
+			field.Type = b.inferType(value, prefix+fieldName, fieldPath, comment)
+		}
+		b.applyType(field)
+		b.applySecret(field)
+		b.applyEnum(field, prefix+fieldName)
+		sd.Fields = append(sd.Fields, field)
+	}
+
+	return structName
+}
+
+// inferType gets the Go type of a YAML value, generating nested structs as needed
+func (b *structBuilder) inferType(node *yaml.Node, typeName, path, comment string) *TypeRef {
+	node = resolveAlias(node)
+	switch node.Kind {
+	case yaml.MappingNode:
+		// Nested struct
+		return &TypeRef{Kind: KindStruct, Name: b.buildStruct(node, typeName, path, comment)}
+	case yaml.SequenceNode:
+		// Array/slice
+		if len(node.Content) == 0 {
+			return &TypeRef{Kind: KindSlice, Elem: &TypeRef{Kind: KindAny}}
+		}
+		if name := b.numberType(node.Content); name != "" {
+			return &TypeRef{Kind: KindSlice, Elem: &TypeRef{Kind: KindScalar, Name: name}}
+		}
+		return &TypeRef{Kind: KindSlice, Elem: b.inferType(node.Content[0], typeName+"Elem", path, comment)}
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!str", "!!timestamp", "!!binary":
+			return &TypeRef{Kind: KindScalar, Name: "string"}
+		case "!!int", "!!float":
+			return &TypeRef{Kind: KindScalar, Name: b.numberType([]*yaml.Node{node})}
+		case "!!bool":
+			return &TypeRef{Kind: KindScalar, Name: "bool"}
+		}
+	}
+	return &TypeRef{Kind: KindAny}
+}
+
+// inferMapType gets the Go type of a mapping with dynamic keys from the types of its values
+func (b *structBuilder) inferMapType(node *yaml.Node, typeName, path, comment string) *TypeRef {
+	pairs := mappingPairs(node)
+	if len(pairs) == 0 {
+		return &TypeRef{Kind: KindMap, Elem: &TypeRef{Kind: KindAny}}
+	}
+
+	values := make([]*yaml.Node, len(pairs))
+	for i, kv := range pairs {
+		values[i] = kv[1]
+	}
+	if name := b.numberType(values); name != "" {
+		return &TypeRef{Kind: KindMap, Elem: &TypeRef{Kind: KindScalar, Name: name}}
+	}
+	return &TypeRef{Kind: KindMap, Elem: b.inferType(values[0], typeName+"Value", path, comment)}
+}
+
+// numberType returns the Go type that holds every number in nodes, or an empty
+// string if any node is not a number. Integers are typed as int when they fit
+// in 32 bits, so they cannot overflow on 32-bit platforms, and as int64 or
+// uint64 otherwise. Non-negative hexadecimal, octal and binary literals, such
+// as bit masks and file modes, are typed as uint32 or uint64. Lists mixing
+// integers and floats are typed as float64, or easycfg.Decimal with
+// WithDecimalFloats
+func (b *structBuilder) numberType(nodes []*yaml.Node) string {
+	var literals []string
+	hasFloat := false
+	for _, node := range nodes {
+		node = resolveAlias(node)
+		if node.Kind != yaml.ScalarNode {
+			return ""
+		}
+		switch node.ShortTag() {
+		case "!!int":
+			literals = append(literals, node.Value)
+		case "!!float":
+			hasFloat = true
+		default:
+			return ""
+		}
+	}
+
+	if hasFloat {
+		if b.options.decimalFloats {
+			return decimalType
+		}
+		return "float64"
+	}
+	return intType(literals)
+}
+
+// intType returns the Go type that holds every YAML integer literal, see numberType
+func intType(literals []string) string {
+	unsigned, negative := true, false
+	fitsInt32, fitsInt64, fitsUint32 := true, true, true
+	for _, literal := range literals {
+		unsigned = unsigned && hasBasePrefix(literal)
+		i, err := strconv.ParseInt(literal, 0, 64)
+		if err != nil {
+			// Only integers above math.MaxInt64 do not parse as int64
+			fitsInt32, fitsInt64, fitsUint32 = false, false, false
+			continue
+		}
+		negative = negative || i < 0
+		fitsInt32 = fitsInt32 && i >= math.MinInt32 && i <= math.MaxInt32
+		fitsUint32 = fitsUint32 && i >= 0 && i <= math.MaxUint32
+	}
+
+	switch {
+	case !fitsInt64 && negative:
+		// No integer type holds both, YAML decodes them as int64 and uint64 values
+		return "float64"
+	case unsigned && !negative && fitsUint32:
+		return "uint32"
+	case unsigned && !negative, !fitsInt64:
+		return "uint64"
+	case fitsInt32:
+		return "int"
+	default:
+		return "int64"
+	}
+}
+
+// hasBasePrefix reports whether an integer literal is written in hexadecimal,
+// octal or binary, including YAML 1.1 octals such as 0644
+func hasBasePrefix(literal string) bool {
+	literal = strings.ToLower(strings.TrimLeft(literal, "+-"))
+	if strings.HasPrefix(literal, "0x") || strings.HasPrefix(literal, "0o") || strings.HasPrefix(literal, "0b") {
+		return true
+	}
+	return len(literal) > 1 && literal[0] == '0'
+}
+
+// uniqueName returns name, suffixed with a number if it is already taken
+func (b *structBuilder) uniqueName(name string) string {
+	unique := name
+	for i := 2; b.names[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	b.names[unique] = true
+	return unique
+}
+
+// modelKeys returns the key path constant of every leaf value reachable from the root
+func modelKeys(model *Model) []*KeyDef {
+	byName := make(map[string]*StructDef, len(model.Structs))
+	for _, sd := range model.Structs {
+		byName[sd.Name] = sd
+	}
+
+	var keys []*KeyDef
+	names := map[string]bool{}
+	var walk func(sd *StructDef)
+	walk = func(sd *StructDef) {
+		for _, f := range sd.Fields {
+			// Values inside lists cannot be addressed by a dotted key path
+			if f.Type.Kind == KindStruct {
+				walk(byName[f.Type.Name])
+				continue
+			}
+
+			constName := "Key" + toCamelCase(f.Path)
+			unique := constName
+			for i := 2; names[unique]; i++ {
+				unique = fmt.Sprintf("%s%d", constName, i)
+			}
+			names[unique] = true
+			keys = append(keys, &KeyDef{Const: unique, Path: f.Path})
+		}
+	}
+	walk(model.Root)
+	return keys
+}
+
+// modelImports returns the import specs the default template needs for the model
+func modelImports(model *Model) []string {
+	var usesAny, usesSlice, usesMap, usesRuntimeType bool
+	for _, sd := range model.Structs {
+		for _, f := range sd.Fields {
+			for t := f.Type; t != nil; t = t.Elem {
+				usesAny = usesAny || t.Kind == KindAny
+				usesSlice = usesSlice || t.Kind == KindSlice
+				usesMap = usesMap || t.Kind == KindMap
+				usesRuntimeType = usesRuntimeType || t.Name == secretType || t.Name == decimalType
+			}
+		}
+	}
+
+	// Standard library imports come first, separated from easycfg by an empty entry
+	var imports []string
+	if model.Options.EmbedDefault {
+		imports = append(imports, `_ "embed"`)
+	}
+	if model.Options.CompareMethods && usesMap {
+		imports = append(imports, strconv.Quote("maps"))
+	}
+	if model.Options.CompareMethods && usesAny {
+		imports = append(imports, strconv.Quote("reflect"))
+	}
+	if model.Options.CompareMethods && usesSlice {
+		imports = append(imports, strconv.Quote("slices"))
+	}
+	if model.Options.CompareMethods || model.Options.Loader || len(model.Enums) > 0 || usesRuntimeType {
+		if len(imports) > 0 {
+			imports = append(imports, "")
+		}
+		imports = append(imports, strconv.Quote(modulePath))
+	}
+	return imports
+}
+
+// mappingPairs returns the key/value pairs of a mapping node in document order,
+// expanding merge keys and letting explicit keys override merged ones
+func mappingPairs(node *yaml.Node) [][2]*yaml.Node {
+	var pairs [][2]*yaml.Node
+	index := map[string]int{}
+	add := func(key, value *yaml.Node, override bool) {
+		if i, ok := index[key.Value]; ok {
+			if override {
+				pairs[i][1] = value
+			}
+			return
+		}
+		index[key.Value] = len(pairs)
+		pairs = append(pairs, [2]*yaml.Node{key, value})
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.ShortTag() != "!!merge" {
+			add(key, value, true)
+			continue
+		}
+		value = resolveAlias(value)
+		sources := []*yaml.Node{value}
+		if value.Kind == yaml.SequenceNode {
+			sources = value.Content
+		}
+		for _, src := range sources {
+			for _, kv := range mappingPairs(resolveAlias(src)) {
+				add(kv[0], kv[1], false)
+			}
+		}
+	}
+	return pairs
+}
+
+// resolveAlias follows alias nodes to the node they refer to
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// nodeComment returns the comments of a key and its value without comment
+// markers and directives, one comment line per line
+func nodeComment(key, value *yaml.Node) string {
+	var lines []string
+	for _, comment := range []string{key.HeadComment, key.LineComment, value.LineComment} {
+		for _, line := range strings.Split(comment, "\n") {
+			line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#"))
+			line = strings.TrimSpace(directiveRegexp.ReplaceAllString(line, ""))
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// nodeSample returns the sample value of a scalar or a list of scalars as written in YAML
+func nodeSample(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return node.Value
+	case yaml.SequenceNode:
+		values := make([]string, 0, len(node.Content))
+		for _, elem := range node.Content {
+			elem = resolveAlias(elem)
+			if elem.Kind != yaml.ScalarNode {
+				return ""
+			}
+			values = append(values, elem.Value)
+		}
+		return "[" + strings.Join(values, ", ") + "]"
+	}
+	return ""
+}
+
+// fieldTag returns the struct tag of a field. The comment becomes a doc tag,
+// which RegisterFlags and StructToDocs read as the description of the key
+func fieldTag(key, comment string) string {
+	tag := fmt.Sprintf("yaml:\"%s\" mapstructure:\"%s\"", key, key)
+	doc := strings.Join(strings.Fields(comment), " ")
+	if doc != "" && !strings.Contains(doc, "`") {
+		tag += " doc:" + strconv.Quote(doc)
+	}
+	return tag
+}
+
+// joinPath appends a key to a dotted YAML path
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
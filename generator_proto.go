@@ -0,0 +1,201 @@
+package easycfg
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// protoStructImport is the well-known types file declaring google.protobuf.Value,
+// ListValue and Struct, used for values without a fixed type
+const protoStructImport = "google/protobuf/struct.proto"
+
+// Field numbers 19000 to 19999 are reserved by the Protocol Buffers implementation
+const (
+	protoReservedFirst = 19000
+	protoReservedLast  = 19999
+)
+
+// protoLock is the content of a .proto.lock file, which records the field
+// number of every message field and the number of every enum value ever generated
+type protoLock struct {
+	Messages map[string]map[string]int `yaml:"messages"`
+	Enums    map[string]map[string]int `yaml:"enums,omitempty"`
+}
+
+// applyProtoLock numbers the fields and enum values of a model from the lock
+// file at lockPath, assigning new numbers to new fields and reserving the
+// numbers of removed ones, and returns the updated lock file
+func applyProtoLock(model *Model, lockPath string) (*generatedFile, error) {
+	lock := &protoLock{}
+	data, err := os.ReadFile(lockPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read proto lock file: %v", err)
+	}
+	if err == nil {
+		if err := yaml.Unmarshal(data, lock); err != nil {
+			return nil, fmt.Errorf("failed to parse proto lock file: %v", err)
+		}
+	}
+	if lock.Messages == nil {
+		lock.Messages = map[string]map[string]int{}
+	}
+	if lock.Enums == nil {
+		lock.Enums = map[string]map[string]int{}
+	}
+
+	for _, sd := range model.Structs {
+		numbers := lockNumbers(lock.Messages, sd.Name)
+		current := map[string]bool{}
+		for _, f := range sd.Fields {
+			name := protoFieldName(f.Key)
+			current[name] = true
+			f.Number = lockNumber(numbers, name)
+		}
+
+		// Fields removed from the YAML keep their numbers reserved
+		sd.Reserved = nil
+		for name, number := range numbers {
+			if !current[name] {
+				sd.Reserved = append(sd.Reserved, &ReservedField{Number: number, Name: name})
+			}
+		}
+		sort.Slice(sd.Reserved, func(i, j int) bool { return sd.Reserved[i].Number < sd.Reserved[j].Number })
+	}
+
+	// Enum values start at 1, 0 is the unspecified value
+	for _, ed := range model.Enums {
+		numbers := lockNumbers(lock.Enums, ed.Name)
+		for _, v := range ed.Values {
+			v.Number = lockNumber(numbers, v.Value)
+		}
+	}
+
+	// Proto output only needs the import of well-known types
+	model.Imports = nil
+	if usesProtoStruct(model) {
+		model.Imports = []string{protoStructImport}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("# Field numbers of %s, generated by easycfg from %s.\n", filepath.Base(strings.TrimSuffix(lockPath, ".lock")), model.Source))
+	buf.WriteString("# Keep this file under version control so regeneration never renumbers or reuses fields.\n")
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(lock); err != nil {
+		return nil, fmt.Errorf("failed to encode proto lock file: %v", err)
+	}
+	return &generatedFile{path: lockPath, content: buf.Bytes()}, nil
+}
+
+// lockNumbers returns the numbers recorded for a message or enum, adding an empty set if there are none
+func lockNumbers(lock map[string]map[string]int, name string) map[string]int {
+	if lock[name] == nil {
+		lock[name] = map[string]int{}
+	}
+	return lock[name]
+}
+
+// lockNumber returns the number recorded for name, recording the next unused number if there is none
+func lockNumber(numbers map[string]int, name string) int {
+	if number, ok := numbers[name]; ok {
+		return number
+	}
+
+	next := 1
+	for _, number := range numbers {
+		if number >= next {
+			next = number + 1
+		}
+	}
+	if next >= protoReservedFirst && next <= protoReservedLast {
+		next = protoReservedLast + 1
+	}
+	numbers[name] = next
+	return next
+}
+
+// usesProtoStruct reports whether any field needs the well-known types of struct.proto
+func usesProtoStruct(model *Model) bool {
+	for _, sd := range model.Structs {
+		for _, f := range sd.Fields {
+			if strings.Contains(protoType(f.Type), "google.protobuf.") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// protoType returns the Protocol Buffers type of a field, including the
+// repeated label of lists and the map type of maps
+func protoType(t *TypeRef) string {
+	switch t.Kind {
+	case KindSlice:
+		return "repeated " + protoValueType(t.Elem)
+	case KindMap:
+		return "map<string, " + protoValueType(t.Elem) + ">"
+	}
+	return protoValueType(t)
+}
+
+// protoValueType returns the Protocol Buffers type of a value. Lists and maps
+// nested in lists or maps cannot be declared directly and use well-known types
+func protoValueType(t *TypeRef) string {
+	switch t.Kind {
+	case KindSlice:
+		return "google.protobuf.ListValue"
+	case KindMap:
+		return "google.protobuf.Struct"
+	case KindAny:
+		return "google.protobuf.Value"
+	case KindStruct:
+		return t.Name
+	}
+
+	switch t.Name {
+	case "bool":
+		return "bool"
+	case "string", secretType, decimalType:
+		return "string"
+	case "int", "int8", "int16", "int32":
+		return "int32"
+	case "int64":
+		return "int64"
+	case "uint8", "uint16", "uint32":
+		return "uint32"
+	case "uint", "uint64":
+		return "uint64"
+	case "float32":
+		return "float"
+	case "float64":
+		return "double"
+	default:
+		// Enums are declared with the same name
+		return t.Name
+	}
+}
+
+// protoFieldName converts a YAML key to a lower_snake_case field name
+func protoFieldName(key string) string {
+	words := splitWords(key)
+	for i := range words {
+		words[i] = strings.ToLower(words[i])
+	}
+	return strings.Join(words, "_")
+}
+
+// protoEnumValueName returns the UPPER_SNAKE_CASE name of an enum value,
+// prefixed with the enum name as Protocol Buffers enum values share the package scope
+func protoEnumValueName(enumName, value string) string {
+	words := append(splitWords(enumName), splitWords(value)...)
+	for i := range words {
+		words[i] = strings.ToUpper(words[i])
+	}
+	return strings.Join(words, "_")
+}
@@ -0,0 +1,217 @@
+package easycfg
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// defaultTemplate renders the Go source YamlToStruct generates by default
+//
+//go:embed templates/go.tmpl
+var defaultTemplate string
+
+// tsTemplate renders the TypeScript source YamlToStruct generates with LanguageTypeScript
+//
+//go:embed templates/ts.tmpl
+var tsTemplate string
+
+// protoTemplate renders the Protocol Buffers source YamlToStruct generates with LanguageProto
+//
+//go:embed templates/proto.tmpl
+var protoTemplate string
+
+// tsIdentifierRegexp matches property names that need no quotes in TypeScript
+var tsIdentifierRegexp = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// templateFuncs are the functions available to generator templates:
+//
+//	quote      quotes a string as a Go string literal
+//	join       joins a list of strings with a separator
+//	lower      converts a string to lower case
+//	lines      splits a string into lines
+//	camel      converts a key to CamelCase, as used for Go names
+//	zeroValue  returns the Go literal of the zero value of a *TypeRef
+//	equalExpr  returns a Go expression comparing two values of a *TypeRef
+//	copyStmts  returns Go statements deep copying a value of a *TypeRef, indented by the given prefix
+//	tsType     returns the TypeScript type of a *TypeRef
+//	tsKey      returns a YAML key as a TypeScript property name, quoted if needed
+//	protoType  returns the Protocol Buffers type of a *TypeRef, including repeated and map
+//	protoName  returns a YAML key as a Protocol Buffers field name
+//	protoEnum  returns the Protocol Buffers name of a value of an enum
+var templateFuncs = template.FuncMap{
+	"quote": strconv.Quote,
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"lines": func(s string) []string {
+		return strings.Split(s, "\n")
+	},
+	"camel":     toCamelCase,
+	"zeroValue": zeroValue,
+	"equalExpr": func(a, b string, t *TypeRef) string {
+		return equalExpr(a, b, t, 1)
+	},
+	"copyStmts": func(dst, src string, t *TypeRef, indent string) string {
+		var sb strings.Builder
+		writeCopy(&sb, dst, src, t, indent, 1)
+		return sb.String()
+	},
+	"tsType":    tsType,
+	"protoType": protoType,
+	"protoName": protoFieldName,
+	"protoEnum": protoEnumValueName,
+	"tsKey": func(key string) string {
+		if tsIdentifierRegexp.MatchString(key) {
+			return key
+		}
+		return strconv.Quote(key)
+	},
+}
+
+// loadTemplate parses the default template of a language and the custom templates at path, if any
+func loadTemplate(lang Language, path string) (*template.Template, error) {
+	name, text := "go.tmpl", defaultTemplate
+	switch lang {
+	case LanguageTypeScript:
+		name, text = "ts.tmpl", tsTemplate
+	case LanguageProto:
+		name, text = "proto.tmpl", protoTemplate
+	}
+
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse default template: %v", err)
+	}
+	if path == "" {
+		return tmpl, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %v", err)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		if files, err = filepath.Glob(filepath.Join(path, "*.tmpl")); err != nil {
+			return nil, fmt.Errorf("failed to list templates: %v", err)
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("failed to read template: no *.tmpl files in %s", path)
+		}
+	}
+
+	if _, err := tmpl.ParseFiles(files...); err != nil {
+		return nil, fmt.Errorf("failed to parse template: %v", err)
+	}
+	return tmpl, nil
+}
+
+// writeCopy writes statements assigning a deep copy of src to dst
+func writeCopy(sb *strings.Builder, dst, src string, t *TypeRef, indent string, depth int) {
+	switch t.Kind {
+	case KindScalar:
+		sb.WriteString(fmt.Sprintf("%s%s = %s\n", indent, dst, src))
+	case KindStruct:
+		sb.WriteString(fmt.Sprintf("%s%s = *%s.DeepCopy()\n", indent, dst, src))
+	case KindAny:
+		sb.WriteString(fmt.Sprintf("%s%s = easycfg.DeepCopyValue(%s)\n", indent, dst, src))
+	case KindSlice:
+		sb.WriteString(fmt.Sprintf("%sif %s != nil {\n", indent, src))
+		sb.WriteString(fmt.Sprintf("%s\t%s = make(%s, len(%s))\n", indent, dst, t.GoType(), src))
+		if t.Elem.Kind == KindScalar {
+			sb.WriteString(fmt.Sprintf("%s\tcopy(%s, %s)\n", indent, dst, src))
+		} else {
+			i := fmt.Sprintf("i%d", depth)
+			sb.WriteString(fmt.Sprintf("%s\tfor %s := range %s {\n", indent, i, src))
+			writeCopy(sb, dst+"["+i+"]", src+"["+i+"]", t.Elem, indent+"\t\t", depth+1)
+			sb.WriteString(fmt.Sprintf("%s\t}\n", indent))
+		}
+		sb.WriteString(fmt.Sprintf("%s}\n", indent))
+	case KindMap:
+		k, v := fmt.Sprintf("k%d", depth), fmt.Sprintf("v%d", depth)
+		sb.WriteString(fmt.Sprintf("%sif %s != nil {\n", indent, src))
+		sb.WriteString(fmt.Sprintf("%s\t%s = make(%s, len(%s))\n", indent, dst, t.GoType(), src))
+		sb.WriteString(fmt.Sprintf("%s\tfor %s, %s := range %s {\n", indent, k, v, src))
+		writeCopy(sb, dst+"["+k+"]", v, t.Elem, indent+"\t\t", depth+1)
+		sb.WriteString(fmt.Sprintf("%s\t}\n", indent))
+		sb.WriteString(fmt.Sprintf("%s}\n", indent))
+	}
+}
+
+// equalExpr returns a boolean expression reporting whether a and b are equal
+func equalExpr(a, b string, t *TypeRef, depth int) string {
+	switch t.Kind {
+	case KindStruct:
+		return fmt.Sprintf("%s.Equal(&%s)", a, b)
+	case KindAny:
+		return fmt.Sprintf("reflect.DeepEqual(%s, %s)", a, b)
+	case KindSlice:
+		if t.Elem.Kind == KindScalar {
+			return fmt.Sprintf("slices.Equal(%s, %s)", a, b)
+		}
+		x, y := fmt.Sprintf("x%d", depth), fmt.Sprintf("y%d", depth)
+		return fmt.Sprintf("slices.EqualFunc(%s, %s, func(%s, %s %s) bool { return %s })",
+			a, b, x, y, t.Elem.GoType(), equalExpr(x, y, t.Elem, depth+1))
+	case KindMap:
+		if t.Elem.Kind == KindScalar {
+			return fmt.Sprintf("maps.Equal(%s, %s)", a, b)
+		}
+		x, y := fmt.Sprintf("x%d", depth), fmt.Sprintf("y%d", depth)
+		return fmt.Sprintf("maps.EqualFunc(%s, %s, func(%s, %s %s) bool { return %s })",
+			a, b, x, y, t.Elem.GoType(), equalExpr(x, y, t.Elem, depth+1))
+	default:
+		return fmt.Sprintf("%s == %s", a, b)
+	}
+}
+
+// tsType returns the TypeScript type of a type. Numbers that easycfg.Decimal
+// keeps as text are strings, like in JSON
+func tsType(t *TypeRef) string {
+	switch t.Kind {
+	case KindSlice:
+		return tsType(t.Elem) + "[]"
+	case KindMap:
+		return "Record<string, " + tsType(t.Elem) + ">"
+	case KindAny:
+		return "unknown"
+	case KindStruct:
+		return t.Name
+	}
+	switch {
+	case t.Name == "bool":
+		return "boolean"
+	case t.Name != decimalType && isNumericType(t.Name):
+		return "number"
+	case t.Name == "string", t.Name == secretType, t.Name == decimalType:
+		return "string"
+	default:
+		// Enums are declared as string union types of the same name
+		return t.Name
+	}
+}
+
+// zeroValue returns the Go literal of the zero value of a type
+func zeroValue(t *TypeRef) string {
+	switch t.Kind {
+	case KindScalar:
+		// Scalars other than numbers and bools are string based, including secrets and enums
+		switch {
+		case t.Name == "bool":
+			return "false"
+		case strings.HasPrefix(t.Name, "int"), strings.HasPrefix(t.Name, "uint"), strings.HasPrefix(t.Name, "float"):
+			return "0"
+		default:
+			return `""`
+		}
+	case KindStruct:
+		return t.Name + "{}"
+	default:
+		return "nil"
+	}
+}
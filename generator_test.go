@@ -1,8 +1,13 @@
 package easycfg
 
 import (
+	"errors"
+	"go/parser"
+	"go/token"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -67,6 +72,927 @@ test:
 	}
 }
 
+func TestYamlToStructCompareMethods(t *testing.T) {
+	// Create test YAML file
+	yamlContent := `
+server:
+  host: localhost
+  ports: [80, 443]
+  routes:
+    - path: /api
+      methods: [GET]
+matrix:
+  - [1, 2]
+extra: null
+`
+	tempDir := t.TempDir()
+	yamlPath := filepath.Join(tempDir, "app.yml")
+	if err := os.WriteFile(yamlPath, []byte(yamlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test YAML file: %v", err)
+	}
+
+	// Execute test
+	outputDir := filepath.Join(tempDir, "generated")
+	if err := YamlToStruct(yamlPath, outputDir, "appconfig", WithCompareMethods()); err != nil {
+		t.Fatalf("YamlToStruct failed: %v", err)
+	}
+
+	generatedFilePath := filepath.Join(outputDir, "app.go")
+	content, err := os.ReadFile(generatedFilePath)
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+
+	// Generated code must be valid Go
+	if _, err := parser.ParseFile(token.NewFileSet(), generatedFilePath, content, 0); err != nil {
+		t.Fatalf("Generated file is not valid Go: %v", err)
+	}
+
+	expectedContent := []string{
+		"\"github.com/chiayu0816/easycfg\"",
+		"func (c *App) DeepCopy() *App",
+		"func (c *App) Equal(other *App) bool",
+		"func (c *App) Diff(other *App) []easycfg.Change",
+		"func (c *ServerRoutesElem) Diff(other *ServerRoutesElem) []easycfg.Change",
+		"Routes []ServerRoutesElem",
+		"out.Routes[i1] = *c.Routes[i1].DeepCopy()",
+		"slices.Equal(c.Ports, other.Ports)",
+		"reflect.DeepEqual(c.Extra, other.Extra)",
+		"change.Path = \"server.\" + change.Path",
+	}
+
+	contentStr := string(content)
+	for _, expected := range expectedContent {
+		if !strings.Contains(contentStr, expected) {
+			t.Errorf("Generated file is missing expected content: %s", expected)
+		}
+	}
+
+	// The generated methods compile and copy, compare and diff values
+	output := runGenerated(t, tempDir, `
+	a := &appconfig.App{
+		Server: appconfig.Server{Host: "a", Ports: []int{80}, Routes: []appconfig.ServerRoutesElem{{Path: "/api", Methods: []string{"GET"}}}},
+		Matrix: [][]int{{1, 2}},
+	}
+	b := a.DeepCopy()
+	b.Server.Host = "b"
+	b.Server.Routes[0].Methods[0] = "POST"
+	b.Matrix[0][0] = 3
+	fmt.Println(a.Equal(a.DeepCopy()), a.Equal(b), a.Server.Routes[0].Methods[0], a.Matrix[0][0])
+	for _, change := range a.Diff(b) {
+		fmt.Println(change.Path, change.Old, change.New)
+	}`)
+	want := "true false GET 1\nserver.host a b\nserver.routes [{/api [GET]}] [{/api [POST]}]\nmatrix [[1 2]] [[3 2]]\n"
+	if output != want {
+		t.Errorf("Generated methods printed:\n%s\nexpected:\n%s", output, want)
+	}
+}
+
+// runGenerated runs main, the body of a main function using the package
+// generated in dir/generated as appconfig, in a module of dir that uses this
+// repository as easycfg, and returns its output. It is skipped in short mode
+func runGenerated(t *testing.T, dir, main string) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("Skipping build of generated code in short mode")
+	}
+	goCmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("Skipping build of generated code without the go command")
+	}
+	repo, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+
+	// The module requires the dependencies of easycfg at the same versions
+	goMod, err := os.ReadFile(filepath.Join(repo, "go.mod"))
+	if err != nil {
+		t.Fatalf("Failed to read go.mod: %v", err)
+	}
+	goSum, err := os.ReadFile(filepath.Join(repo, "go.sum"))
+	if err != nil {
+		t.Fatalf("Failed to read go.sum: %v", err)
+	}
+	module := strings.Replace(string(goMod), "module github.com/chiayu0816/easycfg", "module generatedtest", 1) +
+		"\nrequire github.com/chiayu0816/easycfg v0.0.0\n\nreplace github.com/chiayu0816/easycfg => " + repo + "\n"
+	source := "package main\n\nimport (\n\t\"fmt\"\n\n\tappconfig \"generatedtest/generated\"\n)\n\nfunc main() {" + main + "\n}\n"
+	files := map[string]string{"go.mod": module, "go.sum": string(goSum), "main.go": source}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	cmd := exec.Command(goCmd, "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOWORK=off")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Generated code failed: %v\n%s", err, output)
+	}
+	return string(output)
+}
+
+func TestYamlToStructAccessors(t *testing.T) {
+	// Create test YAML file
+	yamlContent := `
+general:
+  server:
+    port: ":9311"
+  ws_listen_port: 8081
+  addrs: ["a"]
+logger:
+  level: debug
+`
+	tempDir := t.TempDir()
+	yamlPath := filepath.Join(tempDir, "app.yml")
+	if err := os.WriteFile(yamlPath, []byte(yamlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test YAML file: %v", err)
+	}
+
+	// Execute test
+	outputDir := filepath.Join(tempDir, "generated")
+	if err := YamlToStruct(yamlPath, outputDir, "appconfig", WithKeyConstants(), WithGetters()); err != nil {
+		t.Fatalf("YamlToStruct failed: %v", err)
+	}
+
+	generatedFilePath := filepath.Join(outputDir, "app.go")
+	content, err := os.ReadFile(generatedFilePath)
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+
+	// Generated code must be valid Go
+	if _, err := parser.ParseFile(token.NewFileSet(), generatedFilePath, content, 0); err != nil {
+		t.Fatalf("Generated file is not valid Go: %v", err)
+	}
+
+	expectedContent := []string{
+		"KeyGeneralServerPort = \"general.server.port\"",
+		"KeyGeneralWsListenPort = \"general.ws_listen_port\"",
+		"KeyGeneralAddrs = \"general.addrs\"",
+		"KeyLoggerLevel = \"logger.level\"",
+		"func (c *App) GetGeneral() *General",
+		"func (c *General) GetServer() *GeneralServer",
+		"func (c *GeneralServer) GetPort() string",
+		"func (c *General) GetWsListenPort() int",
+		"func (c *General) GetAddrs() []string",
+	}
+
+	contentStr := string(content)
+	for _, expected := range expectedContent {
+		if !strings.Contains(contentStr, expected) {
+			t.Errorf("Generated file is missing expected content: %s", expected)
+		}
+	}
+
+	// Only leaf values get key constants
+	if strings.Contains(contentStr, "KeyGeneralServer =") {
+		t.Errorf("Generated file contains a key constant for a nested struct")
+	}
+}
+
+func TestYamlToStructLoader(t *testing.T) {
+	// Create test YAML file
+	yamlContent := `
+server:
+  port: 8080
+`
+	tempDir := t.TempDir()
+	yamlPath := filepath.Join(tempDir, "app.yml")
+	if err := os.WriteFile(yamlPath, []byte(yamlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test YAML file: %v", err)
+	}
+
+	// Execute test
+	outputDir := filepath.Join(tempDir, "generated")
+	if err := YamlToStruct(yamlPath, outputDir, "appconfig", WithEmbeddedDefault()); err != nil {
+		t.Fatalf("YamlToStruct failed: %v", err)
+	}
+
+	generatedFilePath := filepath.Join(outputDir, "app.go")
+	content, err := os.ReadFile(generatedFilePath)
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+
+	// Generated code must be valid Go
+	if _, err := parser.ParseFile(token.NewFileSet(), generatedFilePath, content, 0); err != nil {
+		t.Fatalf("Generated file is not valid Go: %v", err)
+	}
+
+	expectedContent := []string{
+		"_ \"embed\"",
+		"//go:embed app.yml",
+		"var defaultAppYaml []byte",
+		"func LoadApp(path string, opts ...easycfg.Option) (*App, error)",
+		"func WatchApp(path string, onChange func(*App), opts ...easycfg.Option) (*App, error)",
+		"easycfg.WithConfigData(defaultAppYaml, \"yaml\")",
+	}
+
+	contentStr := string(content)
+	for _, expected := range expectedContent {
+		if !strings.Contains(contentStr, expected) {
+			t.Errorf("Generated file is missing expected content: %s", expected)
+		}
+	}
+
+	// The embedded YAML must be copied next to the generated file
+	embedded, err := os.ReadFile(filepath.Join(outputDir, "app.yml"))
+	if err != nil {
+		t.Fatalf("Embedded YAML file was not copied: %v", err)
+	}
+	if string(embedded) != yamlContent {
+		t.Errorf("Embedded YAML file content = %q, expected %q", embedded, yamlContent)
+	}
+
+	// The generated functions compile and load the embedded YAML without a path
+	output := runGenerated(t, tempDir, `
+	cfg, err := appconfig.LoadApp("")
+	fmt.Println(cfg.Server.Port, err)
+	watched, err := appconfig.WatchApp("", nil)
+	fmt.Println(watched.Server.Port, err)`)
+	if want := "8080 <nil>\n8080 <nil>\n"; output != want {
+		t.Errorf("Generated functions printed %q, expected %q", output, want)
+	}
+}
+
+func TestYamlToStructSecrets(t *testing.T) {
+	// Create test YAML file
+	yamlContent := `
+redis:
+  password: password123
+  api_key: abc
+  db_token: [a, b]
+  # easycfg:secret
+  conn: "user:pw@host"
+  passwordFile: /etc/pw # easycfg:secret=false
+  keys: [a]
+  port: 6379
+`
+	tempDir := t.TempDir()
+	yamlPath := filepath.Join(tempDir, "app.yml")
+	if err := os.WriteFile(yamlPath, []byte(yamlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test YAML file: %v", err)
+	}
+
+	outputDir := filepath.Join(tempDir, "generated")
+	generatedFilePath := filepath.Join(outputDir, "app.go")
+
+	// Without detection only marked keys are secret
+	if err := YamlToStruct(yamlPath, outputDir, "appconfig"); err != nil {
+		t.Fatalf("YamlToStruct failed: %v", err)
+	}
+	content, err := os.ReadFile(generatedFilePath)
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+	for _, expected := range []string{"Password string", "Conn easycfg.Secret", "\"github.com/chiayu0816/easycfg\""} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Generated file is missing expected content: %s", expected)
+		}
+	}
+
+	// With detection secret-looking keys are secret too
+	if err := YamlToStruct(yamlPath, outputDir, "appconfig", WithSecretDetection()); err != nil {
+		t.Fatalf("YamlToStruct failed: %v", err)
+	}
+	content, err = os.ReadFile(generatedFilePath)
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+
+	expectedContent := []string{
+		"Password easycfg.Secret",
+		"ApiKey easycfg.Secret",
+		"DbToken []easycfg.Secret",
+		"Conn easycfg.Secret",
+		"PasswordFile string",
+		"Keys []string",
+		"Port int",
+	}
+	for _, expected := range expectedContent {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Generated file is missing expected content: %s", expected)
+		}
+	}
+}
+
+func TestYamlToStructEnums(t *testing.T) {
+	// Create test YAML file
+	yamlContent := `
+general:
+  type: exchange # easycfg:enum=exchange,futures
+logger:
+  level: debug
+  outputs: [stdout]
+`
+	mappingContent := `
+logger.level:
+  enum: [debug, info, warn, error]
+logger.outputs:
+  enum: stdout,file
+`
+	tempDir := t.TempDir()
+	yamlPath := filepath.Join(tempDir, "app.yml")
+	if err := os.WriteFile(yamlPath, []byte(yamlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test YAML file: %v", err)
+	}
+	mappingPath := filepath.Join(tempDir, "mapping.yml")
+	if err := os.WriteFile(mappingPath, []byte(mappingContent), 0644); err != nil {
+		t.Fatalf("Failed to create mapping file: %v", err)
+	}
+
+	// Execute test
+	outputDir := filepath.Join(tempDir, "generated")
+	if err := YamlToStruct(yamlPath, outputDir, "appconfig", WithMappingFile(mappingPath)); err != nil {
+		t.Fatalf("YamlToStruct failed: %v", err)
+	}
+
+	generatedFilePath := filepath.Join(outputDir, "app.go")
+	content, err := os.ReadFile(generatedFilePath)
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+
+	// Generated code must be valid Go
+	if _, err := parser.ParseFile(token.NewFileSet(), generatedFilePath, content, 0); err != nil {
+		t.Fatalf("Generated file is not valid Go: %v", err)
+	}
+
+	expectedContent := []string{
+		"Type GeneralType",
+		"type GeneralType string",
+		"GeneralTypeExchange GeneralType = \"exchange\"",
+		"GeneralTypeFutures GeneralType = \"futures\"",
+		"Level LoggerLevel",
+		"LoggerLevelWarn LoggerLevel = \"warn\"",
+		"Outputs []LoggerOutputs",
+		"func (v LoggerLevel) IsValid() bool",
+		"func (v LoggerLevel) EnumValues() []string",
+		"func (v *LoggerLevel) UnmarshalText(text []byte) error",
+		"&easycfg.EnumError{Value: string(text), Allowed: value.EnumValues()}",
+	}
+
+	contentStr := string(content)
+	for _, expected := range expectedContent {
+		if !strings.Contains(contentStr, expected) {
+			t.Errorf("Generated file is missing expected content: %s", expected)
+		}
+	}
+}
+
+func TestYamlToStructCustomTemplate(t *testing.T) {
+	// Create test YAML file
+	yamlContent := `
+server:
+  # Address the server listens on
+  port: ":9311" # easycfg:secret
+  hosts: [a, b]
+`
+	tempDir := t.TempDir()
+	yamlPath := filepath.Join(tempDir, "app.yml")
+	if err := os.WriteFile(yamlPath, []byte(yamlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test YAML file: %v", err)
+	}
+
+	// Redefining a single template keeps the rest of the default output
+	headerPath := filepath.Join(tempDir, "header.tmpl")
+	headerTemplate := `{{define "header"}}// Copyright ACME Corp.
+// Code generated from {{.Source}}; DO NOT EDIT.
+{{end}}`
+	if err := os.WriteFile(headerPath, []byte(headerTemplate), 0644); err != nil {
+		t.Fatalf("Failed to create template file: %v", err)
+	}
+
+	outputDir := filepath.Join(tempDir, "generated")
+	generatedFilePath := filepath.Join(outputDir, "app.go")
+	if err := YamlToStruct(yamlPath, outputDir, "appconfig", WithTemplate(headerPath)); err != nil {
+		t.Fatalf("YamlToStruct failed: %v", err)
+	}
+	content, err := os.ReadFile(generatedFilePath)
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+	for _, expected := range []string{"// Copyright ACME Corp.\n// Code generated from app.yml; DO NOT EDIT.\npackage appconfig", "Port easycfg.Secret"} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Generated file is missing expected content: %s", expected)
+		}
+	}
+
+	// A template directory can replace the whole output
+	templateDir := filepath.Join(tempDir, "templates")
+	if err := os.MkdirAll(templateDir, 0755); err != nil {
+		t.Fatalf("Failed to create template directory: %v", err)
+	}
+	fileTemplate := `{{define "file"}}package {{.Package}}
+{{range .Structs}}{{template "field" .}}{{end}}{{end}}`
+	fieldTemplate := `{{define "field"}}{{range .Fields}}// {{.Path}} {{.Type.Kind}} {{.Type.GoType}} sample={{.Sample}} comment={{.Comment}}
+{{end}}{{end}}`
+	if err := os.WriteFile(filepath.Join(templateDir, "file.tmpl"), []byte(fileTemplate), 0644); err != nil {
+		t.Fatalf("Failed to create template file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(templateDir, "field.tmpl"), []byte(fieldTemplate), 0644); err != nil {
+		t.Fatalf("Failed to create template file: %v", err)
+	}
+
+	if err := YamlToStruct(yamlPath, outputDir, "appconfig", WithTemplate(templateDir)); err != nil {
+		t.Fatalf("YamlToStruct failed: %v", err)
+	}
+	content, err = os.ReadFile(generatedFilePath)
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+
+	expected := `package appconfig
+// server struct Server sample= comment=
+// server.port scalar easycfg.Secret sample=:9311 comment=Address the server listens on
+// server.hosts slice []string sample=[a, b] comment=
+`
+	if string(content) != expected {
+		t.Errorf("Generated file = %q, expected %q", content, expected)
+	}
+}
+
+func TestYamlToStructFieldOrder(t *testing.T) {
+	yamlContent := `
+zeta: 1
+alpha: 2
+mid:
+  b: true
+  a: false
+`
+	tempDir := t.TempDir()
+	yamlPath := filepath.Join(tempDir, "order.yml")
+	if err := os.WriteFile(yamlPath, []byte(yamlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test YAML file: %v", err)
+	}
+
+	// Generate twice, output must be identical and follow the YAML key order
+	outputDir := filepath.Join(tempDir, "generated")
+	var previous string
+	for i := 0; i < 2; i++ {
+		if err := YamlToStruct(yamlPath, outputDir, "order"); err != nil {
+			t.Fatalf("YamlToStruct failed: %v", err)
+		}
+		content, err := os.ReadFile(filepath.Join(outputDir, "order.go"))
+		if err != nil {
+			t.Fatalf("Failed to read generated file: %v", err)
+		}
+		if i > 0 && string(content) != previous {
+			t.Errorf("Generated output is not deterministic")
+		}
+		previous = string(content)
+	}
+
+	if strings.Index(previous, "Zeta int") > strings.Index(previous, "Alpha int") {
+		t.Errorf("Fields are not generated in YAML order:\n%s", previous)
+	}
+	if strings.Index(previous, "B bool") > strings.Index(previous, "A bool") {
+		t.Errorf("Nested fields are not generated in YAML order:\n%s", previous)
+	}
+}
+
+func TestYamlToStructScaffold(t *testing.T) {
+	// Create test YAML file
+	tempDir := t.TempDir()
+	yamlPath := filepath.Join(tempDir, "app.yml")
+	if err := os.WriteFile(yamlPath, []byte("port: 8080\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test YAML file: %v", err)
+	}
+
+	// A file generated without scaffolding is replaced by the scaffold
+	outputDir := filepath.Join(tempDir, "generated")
+	if err := YamlToStruct(yamlPath, outputDir, "appconfig"); err != nil {
+		t.Fatalf("YamlToStruct failed: %v", err)
+	}
+	if err := YamlToStruct(yamlPath, outputDir, "appconfig", WithScaffold()); err != nil {
+		t.Fatalf("YamlToStruct with scaffold failed: %v", err)
+	}
+	userPath := filepath.Join(outputDir, "app.go")
+	userCode, err := os.ReadFile(userPath)
+	if err != nil {
+		t.Fatalf("Failed to read scaffold file: %v", err)
+	}
+	if contains(string(userCode), "type App struct") || !contains(string(userCode), "package appconfig") {
+		t.Errorf("Unexpected scaffold file content:\n%s", userCode)
+	}
+	genCode, err := os.ReadFile(filepath.Join(outputDir, "app_gen.go"))
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+	if !contains(string(genCode), "// Code generated by easycfg from app.yml. DO NOT EDIT.") || !contains(string(genCode), "Port int") {
+		t.Errorf("Unexpected generated file content:\n%s", genCode)
+	}
+
+	// Hand-written code survives regeneration
+	handWritten := string(userCode) + "\nfunc (c *App) Addr() string { return \"\" }\n"
+	if err := os.WriteFile(userPath, []byte(handWritten), 0644); err != nil {
+		t.Fatalf("Failed to write hand-written code: %v", err)
+	}
+	if err := YamlToStruct(yamlPath, outputDir, "appconfig", WithScaffold()); err != nil {
+		t.Fatalf("YamlToStruct regeneration failed: %v", err)
+	}
+	userCode, err = os.ReadFile(userPath)
+	if err != nil {
+		t.Fatalf("Failed to read scaffold file: %v", err)
+	}
+	if string(userCode) != handWritten {
+		t.Errorf("Hand-written code was modified:\n%s", userCode)
+	}
+
+	// Without scaffolding a hand-written file is never overwritten
+	err = YamlToStruct(yamlPath, outputDir, "appconfig")
+	if err == nil || !contains(err.Error(), "refusing to overwrite") {
+		t.Errorf("Expected refusal to overwrite hand-written file, got %v", err)
+	}
+}
+
+func TestIsGeneratedCode(t *testing.T) {
+	testCases := []struct {
+		content  string
+		expected bool
+	}{
+		{"// Code generated by easycfg from app.yml. DO NOT EDIT.\n\npackage config\n", true},
+		{"// Copyright ACME Corp.\n\n// Code generated by hand; DO NOT EDIT.\npackage config\n", true},
+		{"// This file is automatically generated by easycfg, do not modify manually\npackage config\n", true},
+		{"package config\n\n// Code generated by easycfg from app.yml. DO NOT EDIT.\n", false},
+		{"// Package config holds the configuration.\npackage config\n", false},
+		{"", false},
+	}
+
+	for _, tc := range testCases {
+		if result := isGeneratedCode([]byte(tc.content)); result != tc.expected {
+			t.Errorf("isGeneratedCode(%q) = %v, expected %v", tc.content, result, tc.expected)
+		}
+	}
+}
+
+func TestCheckGenerated(t *testing.T) {
+	// Create test YAML file
+	tempDir := t.TempDir()
+	yamlPath := filepath.Join(tempDir, "app.yml")
+	if err := os.WriteFile(yamlPath, []byte("port: 8080\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test YAML file: %v", err)
+	}
+	outputDir := filepath.Join(tempDir, "generated")
+
+	// Missing output is out of date
+	var outdated *OutdatedError
+	if err := CheckGenerated(yamlPath, outputDir, "appconfig"); !errors.As(err, &outdated) {
+		t.Fatalf("Expected OutdatedError for missing output, got %v", err)
+	}
+	if _, err := os.Stat(outputDir); !os.IsNotExist(err) {
+		t.Errorf("CheckGenerated must not write anything")
+	}
+
+	// Freshly generated output is up to date
+	if err := YamlToStruct(yamlPath, outputDir, "appconfig"); err != nil {
+		t.Fatalf("YamlToStruct failed: %v", err)
+	}
+	if err := CheckGenerated(yamlPath, outputDir, "appconfig"); err != nil {
+		t.Errorf("Expected generated output to be up to date, got %v", err)
+	}
+
+	// Editing the YAML without regenerating is reported with a diff
+	if err := os.WriteFile(yamlPath, []byte("port: 8080\nhost: localhost\n"), 0644); err != nil {
+		t.Fatalf("Failed to update test YAML file: %v", err)
+	}
+	err := CheckGenerated(yamlPath, outputDir, "appconfig")
+	if !errors.As(err, &outdated) {
+		t.Fatalf("Expected OutdatedError after editing the YAML, got %v", err)
+	}
+	generatedFilePath := filepath.Join(outputDir, "app.go")
+	if len(outdated.Paths) != 1 || outdated.Paths[0] != generatedFilePath {
+		t.Errorf("Unexpected outdated paths: %v", outdated.Paths)
+	}
+	if !contains(outdated.Diff, "+\tHost string `yaml:\"host\" mapstructure:\"host\"`") {
+		t.Errorf("Diff is missing the new field:\n%s", outdated.Diff)
+	}
+
+	// Options that change the output are part of the check
+	if err := YamlToStruct(yamlPath, outputDir, "appconfig"); err != nil {
+		t.Fatalf("YamlToStruct failed: %v", err)
+	}
+	if err := CheckGenerated(yamlPath, outputDir, "appconfig", WithGetters()); !errors.As(err, &outdated) {
+		t.Errorf("Expected OutdatedError when options differ, got %v", err)
+	}
+}
+
+func TestYamlToStructSplitFiles(t *testing.T) {
+	// Create test YAML file
+	yamlContent := `
+name: app
+general:
+  server:
+    port: 8080
+logger:
+  level: info # easycfg:enum=debug,info
+`
+	tempDir := t.TempDir()
+	yamlPath := filepath.Join(tempDir, "app.yml")
+	if err := os.WriteFile(yamlPath, []byte(yamlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test YAML file: %v", err)
+	}
+
+	// Files generated from another source are left alone
+	outputDir := filepath.Join(tempDir, "generated")
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		t.Fatalf("Failed to create output directory: %v", err)
+	}
+	otherPath := filepath.Join(outputDir, "other_gen.go")
+	otherCode := "// Code generated by easycfg from other.yml. DO NOT EDIT.\npackage appconfig\n"
+	if err := os.WriteFile(otherPath, []byte(otherCode), 0644); err != nil {
+		t.Fatalf("Failed to create other generated file: %v", err)
+	}
+
+	opts := []GenerateOption{WithSplitFiles(), WithKeyConstants(), WithCompareMethods()}
+	if err := YamlToStruct(yamlPath, outputDir, "appconfig", opts...); err != nil {
+		t.Fatalf("YamlToStruct failed: %v", err)
+	}
+
+	expected := map[string][]string{
+		"app.go":         {"type App struct", "KeyGeneralServerPort", "Name string"},
+		"general_gen.go": {"type General struct", "type GeneralServer struct", `"github.com/chiayu0816/easycfg"`},
+		"logger_gen.go":  {"type Logger struct", "type LoggerLevel string"},
+	}
+	for name, contents := range expected {
+		content, err := os.ReadFile(filepath.Join(outputDir, name))
+		if err != nil {
+			t.Fatalf("Failed to read generated file %s: %v", name, err)
+		}
+		if _, err := parser.ParseFile(token.NewFileSet(), name, content, 0); err != nil {
+			t.Errorf("Generated file %s is not valid Go: %v", name, err)
+		}
+		for _, expected := range contents {
+			if !contains(string(content), expected) {
+				t.Errorf("Generated file %s is missing expected content: %s", name, expected)
+			}
+		}
+	}
+	mainCode, _ := os.ReadFile(filepath.Join(outputDir, "app.go"))
+	if contains(string(mainCode), "type General struct") || contains(string(mainCode), "type LoggerLevel") {
+		t.Errorf("Main file contains section types:\n%s", mainCode)
+	}
+
+	// Removing a section makes its file stale
+	if err := os.WriteFile(yamlPath, []byte("name: app\ngeneral:\n  server:\n    port: 8080\n"), 0644); err != nil {
+		t.Fatalf("Failed to update test YAML file: %v", err)
+	}
+	loggerPath := filepath.Join(outputDir, "logger_gen.go")
+	var outdated *OutdatedError
+	if err := CheckGenerated(yamlPath, outputDir, "appconfig", opts...); !errors.As(err, &outdated) || !slices.Contains(outdated.Paths, loggerPath) {
+		t.Errorf("Expected stale section file to be reported, got %v", err)
+	}
+	if err := YamlToStruct(yamlPath, outputDir, "appconfig", opts...); err != nil {
+		t.Fatalf("YamlToStruct failed: %v", err)
+	}
+	if _, err := os.Stat(loggerPath); !os.IsNotExist(err) {
+		t.Errorf("Stale section file was not removed")
+	}
+	if _, err := os.Stat(otherPath); err != nil {
+		t.Errorf("File generated from another source was removed: %v", err)
+	}
+	if err := CheckGenerated(yamlPath, outputDir, "appconfig", opts...); err != nil {
+		t.Errorf("Expected generated output to be up to date, got %v", err)
+	}
+}
+
+func TestYamlToStructNumericTypes(t *testing.T) {
+	// Create test YAML file
+	yamlContent := `
+port: 8080
+offset: -2147483648
+user_id: 9007199254740993
+balance: -9223372036854775808
+max_bytes: 18446744073709551615
+mode: 0644
+flags: 0x1F
+big_mask: 0xFFFFFFFFFF
+ratio: 0.1
+ids: [1, 5000000000]
+weights: [1, 2.5]
+price: 19.99 # easycfg:type=decimal
+retries: 3 # easycfg:type=uint8
+`
+	tempDir := t.TempDir()
+	yamlPath := filepath.Join(tempDir, "numbers.yml")
+	if err := os.WriteFile(yamlPath, []byte(yamlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test YAML file: %v", err)
+	}
+
+	outputDir := filepath.Join(tempDir, "generated")
+	if err := YamlToStruct(yamlPath, outputDir, "numbers"); err != nil {
+		t.Fatalf("YamlToStruct failed: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(outputDir, "numbers.go"))
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+	for _, expected := range []string{
+		"Port int ",
+		"Offset int ",
+		"UserId int64 ",
+		"Balance int64 ",
+		"MaxBytes uint64 ",
+		"Mode uint32 ",
+		"Flags uint32 ",
+		"BigMask uint64 ",
+		"Ratio float64 ",
+		"Ids []int64 ",
+		"Weights []float64 ",
+		"Price easycfg.Decimal ",
+		"Retries uint8 ",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Generated file is missing expected content: %s", expected)
+		}
+	}
+
+	// Decimal floats apply to every float
+	if err := YamlToStruct(yamlPath, outputDir, "numbers", WithDecimalFloats()); err != nil {
+		t.Fatalf("YamlToStruct failed: %v", err)
+	}
+	content, err = os.ReadFile(filepath.Join(outputDir, "numbers.go"))
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+	for _, expected := range []string{"Ratio easycfg.Decimal ", "Weights []easycfg.Decimal ", "Port int "} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Generated file is missing expected content: %s", expected)
+		}
+	}
+}
+
+func TestIntType(t *testing.T) {
+	testCases := []struct {
+		literals []string
+		expected string
+	}{
+		{[]string{"0"}, "int"},
+		{[]string{"2147483647"}, "int"},
+		{[]string{"-2147483648"}, "int"},
+		{[]string{"2147483648"}, "int64"},
+		{[]string{"-2147483649"}, "int64"},
+		{[]string{"9223372036854775807"}, "int64"},
+		{[]string{"9223372036854775808"}, "uint64"},
+		{[]string{"18446744073709551615"}, "uint64"},
+		{[]string{"0x0"}, "uint32"},
+		{[]string{"0xFFFFFFFF"}, "uint32"},
+		{[]string{"0x100000000"}, "uint64"},
+		{[]string{"0o755"}, "uint32"},
+		{[]string{"0755"}, "uint32"},
+		{[]string{"0b1010"}, "uint32"},
+		{[]string{"-0x10"}, "int"},
+		{[]string{"+42"}, "int"},
+		{[]string{"1_000_000"}, "int"},
+		{[]string{"0x10", "20"}, "int"},
+		{[]string{"1", "-1", "4294967296"}, "int64"},
+		{[]string{"-1", "18446744073709551615"}, "float64"},
+	}
+
+	for _, tc := range testCases {
+		if result := intType(tc.literals); result != tc.expected {
+			t.Errorf("intType(%q) = %q, expected %q", tc.literals, result, tc.expected)
+		}
+	}
+}
+
+func TestYamlToStructTypeScript(t *testing.T) {
+	// Create test YAML file
+	yamlContent := `
+name: app
+http-port: 8080
+price: 1.5 # easycfg:type=decimal
+general:
+  # Listen port
+  port: 8080
+  hosts: [a, b]
+  password: hunter2 # easycfg:secret
+  extra: ~
+logger:
+  level: info # easycfg:enum=debug,info
+servers:
+  - host: x
+    enabled: true
+`
+	tempDir := t.TempDir()
+	yamlPath := filepath.Join(tempDir, "app.yml")
+	if err := os.WriteFile(yamlPath, []byte(yamlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test YAML file: %v", err)
+	}
+
+	outputDir := filepath.Join(tempDir, "generated")
+	if err := YamlToStruct(yamlPath, outputDir, "appconfig", WithLanguage(LanguageTypeScript), WithSplitFiles()); err != nil {
+		t.Fatalf("YamlToStruct failed: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(outputDir, "app.ts"))
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+	for _, expected := range []string{
+		"// Code generated by easycfg from app.yml. DO NOT EDIT.",
+		"export interface App {\n  name: string;\n  \"http-port\": number;\n  price: string;\n  general: General;\n  logger: Logger;\n  servers: ServersElem[];\n}",
+		"  // Listen port\n  port: number;\n  hosts: string[];\n  password: string;\n  extra?: unknown;\n",
+		"export interface ServersElem {\n  host: string;\n  enabled: boolean;\n}",
+		`export type LoggerLevel = "debug" | "info";`,
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Generated file is missing expected content: %s", expected)
+		}
+	}
+
+	// Go only options do not produce Go files
+	if matches, _ := filepath.Glob(filepath.Join(outputDir, "*.go")); len(matches) > 0 {
+		t.Errorf("Unexpected Go files: %v", matches)
+	}
+
+	if err := YamlToStruct(yamlPath, outputDir, "appconfig", WithLanguage("rust")); err == nil {
+		t.Errorf("Expected error for unsupported language")
+	}
+}
+
+func TestYamlToStructProto(t *testing.T) {
+	// Create test YAML file
+	yamlContent := `
+name: app
+http-port: 8080
+general:
+  # Listen port
+  port: 8080
+  hosts: [a, b]
+  extra: ~
+logger:
+  level: info # easycfg:enum=debug,info
+servers:
+  - host: x
+    enabled: true
+limits: # easycfg:map
+  alice: 10
+  bob: 5000000000
+backends: # easycfg:map
+  primary:
+    url: http://a
+`
+	tempDir := t.TempDir()
+	yamlPath := filepath.Join(tempDir, "app.yml")
+	if err := os.WriteFile(yamlPath, []byte(yamlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test YAML file: %v", err)
+	}
+
+	outputDir := filepath.Join(tempDir, "generated")
+	if err := YamlToStruct(yamlPath, outputDir, "app.config", WithLanguage(LanguageProto)); err != nil {
+		t.Fatalf("YamlToStruct failed: %v", err)
+	}
+	protoPath := filepath.Join(outputDir, "app.proto")
+	content, err := os.ReadFile(protoPath)
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+	for _, expected := range []string{
+		"// Code generated by easycfg from app.yml. DO NOT EDIT.",
+		"syntax = \"proto3\";\n\npackage app.config;\n\nimport \"google/protobuf/struct.proto\";",
+		"message App {\n  string name = 1;\n  int32 http_port = 2;\n  General general = 3;\n  Logger logger = 4;\n  repeated ServersElem servers = 5;\n  map<string, int64> limits = 6;\n  map<string, BackendsValue> backends = 7;\n}",
+		"  // Listen port\n  int32 port = 1;\n  repeated string hosts = 2;\n  google.protobuf.Value extra = 3;\n",
+		"message BackendsValue {\n  string url = 1;\n}",
+		"enum LoggerLevel {\n  LOGGER_LEVEL_UNSPECIFIED = 0;\n  LOGGER_LEVEL_DEBUG = 1;\n  LOGGER_LEVEL_INFO = 2;\n}",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Generated file is missing expected content: %s", expected)
+		}
+	}
+	if _, err := os.Stat(protoPath + ".lock"); err != nil {
+		t.Fatalf("Expected lock file: %v", err)
+	}
+
+	// Removing and adding keys keeps the numbers of the others and reserves removed ones
+	yamlContent = strings.Replace(yamlContent, "http-port: 8080\n", "", 1) + "debug: true\n"
+	if err := os.WriteFile(yamlPath, []byte(yamlContent), 0644); err != nil {
+		t.Fatalf("Failed to update test YAML file: %v", err)
+	}
+	if err := YamlToStruct(yamlPath, outputDir, "app.config", WithLanguage(LanguageProto)); err != nil {
+		t.Fatalf("YamlToStruct failed: %v", err)
+	}
+	content, err = os.ReadFile(protoPath)
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+	for _, expected := range []string{
+		"  string name = 1;\n  General general = 3;\n",
+		"  bool debug = 8;\n  reserved 2;\n  reserved \"http_port\";\n}",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Regenerated file is missing expected content: %s", expected)
+		}
+	}
+
+	// The lock file is part of the generated output checked by CheckGenerated
+	if err := CheckGenerated(yamlPath, outputDir, "app.config", WithLanguage(LanguageProto)); err != nil {
+		t.Errorf("Expected generated files to be up to date: %v", err)
+	}
+}
+
 func TestToCamelCase(t *testing.T) {
 	testCases := []struct {
 		input    string
@@ -0,0 +1,80 @@
+package easycfg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	yamlPath := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(yamlPath, []byte("server:\n  host: localhost\n  port: 8080\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test YAML file: %v", err)
+	}
+
+	cfg, err := Load[TestConfig](yamlPath)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Server.Host != "localhost" || cfg.Server.Port != 8080 {
+		t.Errorf("cfg.Server = %+v, expected localhost:8080", cfg.Server)
+	}
+
+	if _, err := Load[int](yamlPath); err == nil {
+		t.Errorf("Expected error for a type that is not a struct")
+	}
+	if err := LoadConfig(yamlPath, TestConfig{}); err == nil || !strings.Contains(err.Error(), "easycfg.TestConfig is not a pointer") {
+		t.Errorf("Expected error for a struct that is not a pointer, got %v", err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	yamlPath := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(yamlPath, []byte("server:\n  host: localhost\n  port: 8080\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test YAML file: %v", err)
+	}
+
+	changed := make(chan *TestConfig, 10)
+	w, err := Watch(yamlPath, func(cfg *TestConfig) {
+		changed <- cfg
+	})
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	defer w.Close()
+	first := w.Get()
+	if first.Server.Port != 8080 {
+		t.Errorf("first.Server.Port = %d, expected 8080", first.Server.Port)
+	}
+
+	// Reloads replace the configuration instead of modifying it
+	if err := os.WriteFile(yamlPath, []byte("server:\n  host: localhost\n  port: 9090\n"), 0644); err != nil {
+		t.Fatalf("Failed to update test YAML file: %v", err)
+	}
+	select {
+	case cfg := <-changed:
+		if cfg.Server.Port != 9090 || w.Get() != cfg {
+			t.Errorf("Reloaded port = %d, expected 9090 from Get", cfg.Server.Port)
+		}
+		if first.Server.Port != 8080 {
+			t.Errorf("first.Server.Port = %d after reload, expected 8080", first.Server.Port)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Timed out waiting for the reload")
+	}
+
+	// No reloads follow Close
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := os.WriteFile(yamlPath, []byte("server:\n  port: 7070\n"), 0644); err != nil {
+		t.Fatalf("Failed to update test YAML file: %v", err)
+	}
+	select {
+	case cfg := <-changed:
+		t.Errorf("Reloaded after Close: %+v", cfg)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -10,11 +11,39 @@ import (
 
 // Run executes the CLI command
 func Run() {
+	// Subcommands are given before their flags
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "docs":
+			runDocs(os.Args[2:])
+			return
+		case "sample":
+			runSample(os.Args[2:])
+			return
+		case "generate":
+			runGenerate(os.Args[2:])
+			return
+		}
+	}
+
 	// Define command line parameters
 	yamlPath := flag.String("yaml", "", "Path to YAML configuration file")
 	outputDir := flag.String("output", "generated", "Output directory for generated Go files")
 	packageName := flag.String("package", "config", "Package name for generated Go files")
+	lang := flag.String("lang", string(easycfg.LanguageGo), "Language of the generated code: go, ts or proto")
 	watch := flag.Bool("watch", false, "Whether to watch for configuration file changes")
+	compare := flag.Bool("compare", false, "Generate DeepCopy, Equal and Diff methods for config types")
+	keys := flag.Bool("keys", false, "Generate constants for every configuration key path")
+	getters := flag.Bool("getters", false, "Generate nil-safe getter methods for every field")
+	loader := flag.Bool("loader", false, "Generate typed Load and Watch functions for the root struct")
+	embed := flag.Bool("embed", false, "Embed the YAML file as the default configuration of the generated Load function")
+	secrets := flag.Bool("secrets", false, "Type secret-looking values such as passwords as easycfg.Secret")
+	decimal := flag.Bool("decimal", false, "Type floating point values as easycfg.Decimal, keeping their decimal text")
+	mapping := flag.String("mapping", "", "Path to a YAML file mapping key paths to directives such as enum and secret")
+	templatePath := flag.String("template", "", "Path to a custom text/template file or directory for the generated code")
+	scaffold := flag.Bool("scaffold", false, "Write generated code to <name>_gen.go and create <name>.go once for hand-written code")
+	split := flag.Bool("split", false, "Write the structs of each top-level section to their own <section>_gen.go file")
+	check := flag.Bool("check", false, "Verify that the generated files are up to date without writing them, printing a diff and exiting non-zero otherwise")
 	flag.Parse()
 
 	// Check required parameters
@@ -30,13 +59,64 @@ func Run() {
 		os.Exit(1)
 	}
 
-	// Generate Go struct file
-	if err := easycfg.YamlToStruct(*yamlPath, *outputDir, *packageName); err != nil {
-		fmt.Printf("Error: Failed to generate Go struct: %v\n", err)
-		os.Exit(1)
+	// Collect generator options
+	opts := []easycfg.GenerateOption{easycfg.WithLanguage(easycfg.Language(*lang))}
+	if *compare {
+		opts = append(opts, easycfg.WithCompareMethods())
+	}
+	if *keys {
+		opts = append(opts, easycfg.WithKeyConstants())
+	}
+	if *getters {
+		opts = append(opts, easycfg.WithGetters())
+	}
+	if *secrets {
+		opts = append(opts, easycfg.WithSecretDetection())
+	}
+	if *decimal {
+		opts = append(opts, easycfg.WithDecimalFloats())
+	}
+	if *mapping != "" {
+		opts = append(opts, easycfg.WithMappingFile(*mapping))
+	}
+	if *templatePath != "" {
+		opts = append(opts, easycfg.WithTemplate(*templatePath))
+	}
+	if *loader {
+		opts = append(opts, easycfg.WithLoader())
+	}
+	if *embed {
+		opts = append(opts, easycfg.WithEmbeddedDefault())
+	}
+	if *scaffold {
+		opts = append(opts, easycfg.WithScaffold())
+	}
+	if *split {
+		opts = append(opts, easycfg.WithSplitFiles())
 	}
 
-	fmt.Println("Successfully generated Go struct file")
+	// Compare the generated files with the output of the current YAML file
+	if *check {
+		err := easycfg.CheckGenerated(*yamlPath, *outputDir, *packageName, opts...)
+		var outdated *easycfg.OutdatedError
+		if errors.As(err, &outdated) {
+			fmt.Print(outdated.Diff)
+			fmt.Printf("Error: %v, run easycfgcli without -check to regenerate them\n", err)
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Printf("Error: Failed to check generated files: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Generated files are up to date")
+		return
+	}
+
+	// Generate code file
+	if err := easycfg.YamlToStruct(*yamlPath, *outputDir, *packageName, opts...); err != nil {
+		fmt.Printf("Error: Failed to generate code: %v\n", err)
+		os.Exit(1)
+	}
 
 	// If watch mode is enabled
 	if *watch {
@@ -48,7 +128,7 @@ func Run() {
 		// Watch for YAML file changes using the WatchConfig function
 		if err := easycfg.WatchConfig(*yamlPath, &dummyConfig, func() {
 			// Regenerate Go struct when changes are detected
-			if err := easycfg.YamlToStruct(*yamlPath, *outputDir, *packageName); err != nil {
+			if err := easycfg.YamlToStruct(*yamlPath, *outputDir, *packageName, opts...); err != nil {
 				fmt.Printf("Error: Failed to regenerate Go struct: %v\n", err)
 			} else {
 				fmt.Println("Configuration changes detected, Go struct file has been regenerated")
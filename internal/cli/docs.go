@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/chiayu0816/easycfg"
+)
+
+// runDocs executes the docs command, which renders reference documentation of a YAML file
+func runDocs(args []string) {
+	// Define command line parameters
+	fs := flag.NewFlagSet("docs", flag.ExitOnError)
+	yamlPath := fs.String("yaml", "", "Path to YAML configuration file")
+	outputPath := fs.String("output", "", "Output file for the documentation, standard output if empty")
+	format := fs.String("format", string(easycfg.DocsMarkdown), "Documentation format: markdown or html")
+	envPrefix := fs.String("env-prefix", "", "Prefix of the listed environment variable names")
+	secrets := fs.Bool("secrets", false, "Type secret-looking values such as passwords as secrets, whose values are redacted either way")
+	decimal := fs.Bool("decimal", false, "Document floating point values as decimals")
+	mapping := fs.String("mapping", "", "Path to a YAML file mapping key paths to directives such as enum and secret")
+	fs.Parse(args)
+
+	// Check required parameters
+	if *yamlPath == "" {
+		fmt.Println("Error: YAML configuration file path must be specified")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	// Collect generator options
+	var opts []easycfg.GenerateOption
+	if *envPrefix != "" {
+		opts = append(opts, easycfg.WithDocsEnvPrefix(*envPrefix))
+	}
+	if *secrets {
+		opts = append(opts, easycfg.WithSecretDetection())
+	}
+	if *decimal {
+		opts = append(opts, easycfg.WithDecimalFloats())
+	}
+	if *mapping != "" {
+		opts = append(opts, easycfg.WithMappingFile(*mapping))
+	}
+
+	docs, err := easycfg.YamlToDocs(*yamlPath, easycfg.DocsFormat(*format), opts...)
+	if err != nil {
+		fmt.Printf("Error: Failed to generate documentation: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *outputPath == "" {
+		os.Stdout.Write(docs)
+		return
+	}
+	if err := os.WriteFile(*outputPath, docs, 0644); err != nil {
+		fmt.Printf("Error: Failed to write documentation: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Successfully generated documentation: %s\n", *outputPath)
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/chiayu0816/easycfg"
+)
+
+// runGenerate executes the generate command, which generates every target of a project file
+func runGenerate(args []string) {
+	// Define command line parameters
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	projectPath := fs.String("project", "", "Path to the project file, "+easycfg.ProjectFile+" in the current directory or its parents if empty")
+	all := fs.Bool("all", false, "Generate every target, also when run by go generate")
+	check := fs.Bool("check", false, "Verify that the generated files are up to date without writing them, printing a diff and exiting non-zero otherwise")
+	fs.Parse(args)
+
+	if *projectPath == "" {
+		path, err := easycfg.FindProject(".")
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		*projectPath = path
+	}
+
+	project, err := easycfg.LoadProject(*projectPath)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// go generate runs the command in the package directory, which only generates its own targets
+	dir := ""
+	if os.Getenv("GOPACKAGE") != "" && !*all {
+		dir = "."
+	}
+
+	if *check {
+		err := project.Check(dir)
+		var outdated *easycfg.OutdatedError
+		if errors.As(err, &outdated) {
+			fmt.Print(outdated.Diff)
+			fmt.Printf("Error: %v, run easycfgcli generate without -check to regenerate them\n", err)
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Printf("Error: Failed to check generated files: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Generated files are up to date")
+		return
+	}
+
+	if err := project.Generate(dir); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
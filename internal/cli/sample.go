@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/chiayu0816/easycfg"
+)
+
+// runSample executes the sample command, which writes a sanitized copy of a YAML file
+func runSample(args []string) {
+	// Define command line parameters
+	fs := flag.NewFlagSet("sample", flag.ExitOnError)
+	yamlPath := fs.String("yaml", "", "Path to YAML configuration file")
+	outputPath := fs.String("output", "", "Output file for the sample configuration, standard output if empty")
+	goFile := fs.String("struct", "", "Path to the Go file declaring the configuration struct, whose easycfg.Secret fields are replaced")
+	mapping := fs.String("mapping", "", "Path to a YAML file mapping key paths to directives such as secret")
+	fs.Parse(args)
+
+	// Check required parameters
+	if *yamlPath == "" {
+		fmt.Println("Error: YAML configuration file path must be specified")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	// Collect generator options
+	var opts []easycfg.GenerateOption
+	if *goFile != "" {
+		opts = append(opts, easycfg.WithSampleGoFile(*goFile))
+	}
+	if *mapping != "" {
+		opts = append(opts, easycfg.WithMappingFile(*mapping))
+	}
+
+	sample, err := easycfg.YamlToSample(*yamlPath, opts...)
+	if err != nil {
+		fmt.Printf("Error: Failed to generate sample configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *outputPath == "" {
+		os.Stdout.Write(sample)
+		return
+	}
+	if err := os.WriteFile(*outputPath, sample, 0644); err != nil {
+		fmt.Printf("Error: Failed to write sample configuration: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Successfully generated sample configuration: %s\n", *outputPath)
+}
@@ -0,0 +1,200 @@
+package easycfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvironmentVariable names the environment variable WithEnvironment reads
+// the environment from when none is given
+const EnvironmentVariable = "EASYCFG_ENV"
+
+// configLayer is a configuration file merged into the configuration
+type configLayer struct {
+	path     string
+	optional bool // Optional layers are skipped while the file does not exist
+}
+
+// WithLayers makes LoadConfig and WatchConfig deep-merge the given files over
+// configPath, in order, so that later files override earlier ones. Mappings
+// are merged key by key, lists and scalars are replaced, and null values
+// delete the key, restoring defaults. WatchConfig reloads the configuration
+// whenever any of the files changes
+func WithLayers(paths ...string) Option {
+	return func(o *loadOptions) {
+		for _, path := range paths {
+			o.layers = append(o.layers, configLayer{path: path})
+		}
+	}
+}
+
+// WithEnvironment makes LoadConfig and WatchConfig merge the overlay
+// <name>.<env><ext> next to configPath over it, as with WithLayers, such as
+// config.prod.yml for config.yml and environment "prod". If env is empty,
+// the environment is read from the EASYCFG_ENV variable, and no overlay is
+// merged if that is empty as well. A missing overlay file is not an error
+func WithEnvironment(env string) Option {
+	return func(o *loadOptions) {
+		o.environment = env
+		o.hasEnvironment = true
+	}
+}
+
+// WithListAppend makes layers append to the lists at the given dotted key
+// paths instead of replacing them, or to every list if no path is given
+func WithListAppend(paths ...string) Option {
+	return func(o *loadOptions) {
+		if len(paths) == 0 {
+			o.appendAllLists = true
+		}
+		if o.appendLists == nil {
+			o.appendLists = map[string]bool{}
+		}
+		for _, path := range paths {
+			o.appendLists[strings.ToLower(path)] = true
+		}
+	}
+}
+
+// configLayers returns the files of a configuration in merge order
+func configLayers(configPath string, options *loadOptions) []configLayer {
+	layers := append([]configLayer{{path: configPath}}, options.layers...)
+	if !options.hasEnvironment {
+		return layers
+	}
+
+	env := options.environment
+	if env == "" {
+		env = os.Getenv(EnvironmentVariable)
+	}
+	if env == "" {
+		return layers
+	}
+	ext := filepath.Ext(configPath)
+	overlay := strings.TrimSuffix(configPath, ext) + "." + env + ext
+	return append(layers, configLayer{path: overlay, optional: true})
+}
+
+// readLayers reads and deep-merges the files of a configuration
+func readLayers(layers []configLayer, options *loadOptions) (map[string]interface{}, error) {
+	merged := map[string]interface{}{}
+	for _, layer := range layers {
+		data, err := readLayerFile(layer.path, options)
+		if err != nil && layer.optional && errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read configuration file: %w: %w", ErrConfigNotFound, err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read configuration file: %w", err)
+		}
+
+		values, err := parseLayer(layer.path, data)
+		if err != nil {
+			return nil, newParseError(layer.path, data, err)
+		}
+		mergeLayer(merged, values, "", options)
+	}
+	return merged, nil
+}
+
+// readLayerFile reads a configuration file from the file system of WithFS or the operating system
+func readLayerFile(path string, options *loadOptions) ([]byte, error) {
+	if options.fsys != nil {
+		return fs.ReadFile(options.fsys, path)
+	}
+	return os.ReadFile(path)
+}
+
+// parseLayer decodes a configuration file by its extension. YAML and JSON keep
+// null values, which delete keys when merged; other formats are read by Viper
+func parseLayer(path string, data []byte) (map[string]interface{}, error) {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	var values map[string]interface{}
+	switch ext {
+	case "", "yml", "yaml":
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return nil, err
+		}
+	case "json":
+		if err := json.Unmarshal(data, &values); err != nil {
+			return nil, err
+		}
+	default:
+		v := viper.New()
+		v.SetConfigType(ext)
+		if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+			return nil, err
+		}
+		return v.AllSettings(), nil
+	}
+	return normalizeKeys(values).(map[string]interface{}), nil
+}
+
+// normalizeKeys converts the mappings of a decoded value into maps with lower
+// case string keys, as Viper matches keys case-insensitively
+func normalizeKeys(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(value))
+		for key, v := range value {
+			normalized[strings.ToLower(key)] = normalizeKeys(v)
+		}
+		return normalized
+	case map[interface{}]interface{}:
+		normalized := make(map[string]interface{}, len(value))
+		for key, v := range value {
+			normalized[strings.ToLower(fmt.Sprint(key))] = normalizeKeys(v)
+		}
+		return normalized
+	case []interface{}:
+		for i, v := range value {
+			value[i] = normalizeKeys(v)
+		}
+		return value
+	case nil:
+		return nil
+	default:
+		return value
+	}
+}
+
+// mergeLayer deep-merges the values of a layer into dst, see WithLayers
+func mergeLayer(dst, src map[string]interface{}, path string, options *loadOptions) {
+	if dst == nil {
+		return
+	}
+	for key, value := range src {
+		keyPath := joinPath(path, key)
+		switch value := value.(type) {
+		case nil:
+			delete(dst, key)
+		case map[string]interface{}:
+			existing, ok := dst[key].(map[string]interface{})
+			if !ok {
+				existing = map[string]interface{}{}
+				dst[key] = existing
+			}
+			mergeLayer(existing, value, keyPath, options)
+		case []interface{}:
+			existing, ok := dst[key].([]interface{})
+			if ok && (options.appendAllLists || options.appendLists[keyPath]) {
+				dst[key] = append(append([]interface{}{}, existing...), value...)
+				continue
+			}
+			dst[key] = value
+		default:
+			dst[key] = value
+		}
+	}
+}
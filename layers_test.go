@@ -0,0 +1,120 @@
+package easycfg
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// layerConfig is a configuration struct for layered files
+type layerConfig struct {
+	Server struct {
+		Host  string   `mapstructure:"host"`
+		Port  int      `mapstructure:"port"`
+		Hosts []string `mapstructure:"hosts"`
+	} `mapstructure:"server"`
+	Logging struct {
+		Level string `mapstructure:"level"`
+	} `mapstructure:"logging"`
+}
+
+// writeLayerFiles writes test files into a temporary directory and returns it
+func writeLayerFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestLoadConfigLayers(t *testing.T) {
+	dir := writeLayerFiles(t, map[string]string{
+		"config.yml":       "server:\n  host: base\n  port: 8080\n  hosts: [a, b]\nlogging:\n  level: debug\n",
+		"config.local.yml": "server:\n  Port: 9090\n  hosts: [c]\nlogging:\n  level: ~\n",
+		"config.prod.json": `{"server": {"host": "prod"}}`,
+	})
+	basePath := filepath.Join(dir, "config.yml")
+	localPath := filepath.Join(dir, "config.local.yml")
+
+	// Mappings are merged, lists replaced and null values delete keys, restoring defaults
+	cfg := &layerConfig{}
+	err := LoadConfig(basePath, cfg,
+		WithLayers(localPath, filepath.Join(dir, "config.prod.json")),
+		WithDefaults(map[string]interface{}{"logging.level": "info"}),
+	)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Server.Host != "prod" || cfg.Server.Port != 9090 || cfg.Logging.Level != "info" {
+		t.Errorf("Unexpected configuration: %+v", cfg)
+	}
+	if !reflect.DeepEqual(cfg.Server.Hosts, []string{"c"}) {
+		t.Errorf("cfg.Server.Hosts = %v, expected [c]", cfg.Server.Hosts)
+	}
+
+	// Lists can be appended instead
+	cfg = &layerConfig{}
+	if err := LoadConfig(basePath, cfg, WithLayers(localPath), WithListAppend("server.hosts")); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if !reflect.DeepEqual(cfg.Server.Hosts, []string{"a", "b", "c"}) {
+		t.Errorf("cfg.Server.Hosts = %v, expected [a b c]", cfg.Server.Hosts)
+	}
+
+	// Explicit layers must exist
+	if err := LoadConfig(basePath, &layerConfig{}, WithLayers(filepath.Join(dir, "missing.yml"))); err == nil {
+		t.Errorf("Expected error for a missing layer")
+	}
+
+	// The environment overlay is selected by EASYCFG_ENV and may be missing
+	t.Setenv(EnvironmentVariable, "local")
+	cfg = &layerConfig{}
+	if err := LoadConfig(basePath, cfg, WithEnvironment("")); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Server.Port != 9090 {
+		t.Errorf("cfg.Server.Port = %d, expected 9090", cfg.Server.Port)
+	}
+	cfg = &layerConfig{}
+	if err := LoadConfig(basePath, cfg, WithEnvironment("staging")); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Server.Port != 8080 {
+		t.Errorf("cfg.Server.Port = %d, expected 8080", cfg.Server.Port)
+	}
+}
+
+func TestWatchConfigLayers(t *testing.T) {
+	dir := writeLayerFiles(t, map[string]string{
+		"config.yml": "server:\n  host: base\n  port: 8080\n",
+	})
+	basePath := filepath.Join(dir, "config.yml")
+	overlayPath := filepath.Join(dir, "config.dev.yml")
+
+	cfg := &layerConfig{}
+	changed := make(chan struct{}, 10)
+	err := WatchConfig(basePath, cfg, func() {
+		changed <- struct{}{}
+	}, WithEnvironment("dev"))
+	if err != nil {
+		t.Fatalf("WatchConfig failed: %v", err)
+	}
+
+	// Creating the overlay later reloads the configuration
+	if err := os.WriteFile(overlayPath, []byte("server:\n  port: 9090\n"), 0644); err != nil {
+		t.Fatalf("Failed to create overlay: %v", err)
+	}
+	select {
+	case <-changed:
+		if cfg.Server.Host != "base" || cfg.Server.Port != 9090 {
+			t.Errorf("Unexpected configuration: %+v", cfg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Timed out waiting for the overlay to be loaded")
+	}
+}
@@ -1,81 +1,314 @@
 package easycfg
 
 import (
+	"bytes"
 	"fmt"
-	"path/filepath"
-	"strings"
+	"io/fs"
+	"log/slog"
+	"reflect"
+	"sync"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
 )
 
-// LoadConfig loads configuration from YAML file to the specified struct using Viper
-func LoadConfig(configPath string, configStruct interface{}) error {
-	// Get file name and extension
-	ext := filepath.Ext(configPath)
-	fileName := filepath.Base(configPath)
-	fileNameWithoutExt := strings.TrimSuffix(fileName, ext)
-	dirPath := filepath.Dir(configPath)
-
-	// Initialize Viper
-	v := viper.New()
-	v.SetConfigName(fileNameWithoutExt)
-	v.SetConfigType(strings.TrimPrefix(ext, "."))
-	v.AddConfigPath(dirPath)
+// Option configures optional behavior of LoadConfig and WatchConfig
+type Option func(*loadOptions)
+
+// loadOptions holds the settings collected from Option values
+type loadOptions struct {
+	configData     []byte
+	configType     string
+	env            bool
+	envPrefix      string
+	envSeparator   string
+	defaults       map[string]interface{}
+	strict         bool
+	decodeHooks    []mapstructure.DecodeHookFunc
+	fsys           fs.FS
+	logger         *slog.Logger
+	flags          map[string]string
+	layers         []configLayer
+	environment    string
+	hasEnvironment bool
+	appendLists    map[string]bool
+	appendAllLists bool
+	appName        string
+	searchPaths    []string
+	provenance     *Provenance
+	strictWarn     bool
+	requiredKeys   bool
+}
 
-	// Read configuration file
-	if err := v.ReadInConfig(); err != nil {
-		return fmt.Errorf("failed to read configuration file: %v", err)
+// WithConfigData makes LoadConfig read configuration of the given type (such as "yaml")
+// from data instead of from configPath, for example content embedded with go:embed.
+// WatchConfig loads such configuration once and does not watch it
+func WithConfigData(data []byte, configType string) Option {
+	return func(o *loadOptions) {
+		o.configData = data
+		o.configType = configType
 	}
+}
 
-	// Map configuration to struct
-	if err := v.Unmarshal(configStruct); err != nil {
-		return fmt.Errorf("failed to map configuration to struct: %v", err)
+// WithDefaults makes LoadConfig and WatchConfig use the given values for keys
+// missing from the configuration. Keys are dotted key paths, such as
+// "general.server.port", or hold nested maps of further keys
+func WithDefaults(defaults map[string]interface{}) Option {
+	return func(o *loadOptions) {
+		if o.defaults == nil {
+			o.defaults = map[string]interface{}{}
+		}
+		for key, value := range defaults {
+			o.defaults[key] = value
+		}
 	}
+}
 
-	return nil
+// WithStrict makes LoadConfig and WatchConfig fail on keys of the
+// configuration that do not match any field of the struct, such as a
+// misspelled levle, suggesting the closest key of the struct
+func WithStrict() Option {
+	return func(o *loadOptions) {
+		o.strict = true
+	}
 }
 
-// WatchConfig monitors configuration file changes and automatically reloads
-func WatchConfig(configPath string, configStruct interface{}, onChange func()) error {
-	// Get file name and extension
-	ext := filepath.Ext(configPath)
-	fileName := filepath.Base(configPath)
-	fileNameWithoutExt := strings.TrimSuffix(fileName, ext)
-	dirPath := filepath.Dir(configPath)
-
-	// Initialize Viper
-	v := viper.New()
-	v.SetConfigName(fileNameWithoutExt)
-	v.SetConfigType(strings.TrimPrefix(ext, "."))
-	v.AddConfigPath(dirPath)
+// WithDecodeHook adds a mapstructure decode hook converting configuration
+// values into field types, such as strings into net.IP. Hooks run in the order
+// they are given, before the default ones, which convert strings into
+// time.Duration values and comma-separated strings into slices
+func WithDecodeHook(hook mapstructure.DecodeHookFunc) Option {
+	return func(o *loadOptions) {
+		o.decodeHooks = append(o.decodeHooks, hook)
+	}
+}
 
-	// Read configuration file
-	if err := v.ReadInConfig(); err != nil {
-		return fmt.Errorf("failed to read configuration file: %v", err)
+// WithFS makes LoadConfig read configPath from fsys instead of the operating
+// system's file system, for example an embed.FS. WatchConfig loads such
+// configuration once and does not watch it
+func WithFS(fsys fs.FS) Option {
+	return func(o *loadOptions) {
+		o.fsys = fsys
 	}
+}
 
-	// Map configuration to struct
-	if err := v.Unmarshal(configStruct); err != nil {
-		return fmt.Errorf("failed to map configuration to struct: %v", err)
+// WithLogger makes WatchConfig report reloads and reload failures to logger
+// instead of slog.Default()
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *loadOptions) {
+		o.logger = logger
 	}
+}
 
-	// Monitor configuration file changes
-	v.WatchConfig()
-	v.OnConfigChange(func(e fsnotify.Event) {
-		// Reload configuration
-		if err := v.Unmarshal(configStruct); err != nil {
-			fmt.Printf("failed to reload configuration: %v\n", err)
-			return
-		}
+// LoadConfig loads configuration from YAML file to the specified struct using Viper
+func LoadConfig(configPath string, configStruct interface{}, opts ...Option) error {
+	return loadConfig(configPath, configStruct, newLoadOptions(opts))
+}
+
+// Load is LoadConfig returning a new *T, such as:
+//
+//	cfg, err := easycfg.Load[config.App]("app.yml")
+func Load[T any](configPath string, opts ...Option) (*T, error) {
+	cfg := new(T)
+	if err := loadConfig(configPath, cfg, newLoadOptions(opts)); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// WatchConfig monitors configuration file changes and automatically reloads
+func WatchConfig(configPath string, configStruct interface{}, onChange func(), opts ...Option) error {
+	options := newLoadOptions(opts)
+	if err := loadConfig(configPath, configStruct, options); err != nil {
+		return err
+	}
 
-		// Call callback function
+	// Reloads are copied into configStruct
+	_, err := watchConfig(configPath, reflect.TypeOf(configStruct).Elem(), options, func(cfg interface{}) {
+		reflect.ValueOf(configStruct).Elem().Set(reflect.ValueOf(cfg).Elem())
 		if onChange != nil {
 			onChange()
 		}
+	})
+	return err
+}
+
+// Watcher holds a configuration loaded by Watch, replacing it with a new
+// value whenever its files change
+type Watcher[T any] struct {
+	mu      sync.RWMutex
+	current *T
+	files   *fsnotify.Watcher // nil for configuration that is not watched
+}
+
+// Watch is WatchConfig for a new *T. Unlike WatchConfig, reloads never modify
+// a value returned by Get, so it can be read while the files change. onChange
+// is called with every reloaded configuration
+func Watch[T any](configPath string, onChange func(*T), opts ...Option) (*Watcher[T], error) {
+	options := newLoadOptions(opts)
+	w := &Watcher[T]{current: new(T)}
+	if err := loadConfig(configPath, w.current, options); err != nil {
+		return nil, err
+	}
+
+	var err error
+	w.files, err = watchConfig(configPath, reflect.TypeOf(w.current).Elem(), options, func(cfg interface{}) {
+		w.mu.Lock()
+		w.current = cfg.(*T)
+		w.mu.Unlock()
+		if onChange != nil {
+			onChange(cfg.(*T))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Get returns the current configuration
+func (w *Watcher[T]) Get() *T {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// Close stops watching the configuration files
+func (w *Watcher[T]) Close() error {
+	if w.files == nil {
+		return nil
+	}
+	return w.files.Close()
+}
+
+// loadConfig reads the configuration and maps it to the struct
+func loadConfig(configPath string, configStruct interface{}, options *loadOptions) error {
+	v, err := readConfig(configPath, options)
+	if err != nil {
+		return err
+	}
+	return decodeConfig(v, configStruct, options)
+}
+
+// watchConfig watches every file of the configuration, decoding it into a new
+// value of type t on each change and passing it to apply unless it is
+// invalid. In-memory configuration is not watched, and the returned watcher is nil
+func watchConfig(configPath string, t reflect.Type, options *loadOptions, apply func(cfg interface{})) (*fsnotify.Watcher, error) {
+	if options.configData != nil || options.fsys != nil {
+		return nil, nil
+	}
+
+	var paths []string
+	for _, layer := range configLayers(configPath, options) {
+		paths = append(paths, layer.path)
+	}
+	var mu sync.Mutex
+	return watchFiles(paths, options.logger, func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		// Reload configuration into a new value, keeping the current one if it is invalid
+		cfg := reflect.New(t).Interface()
+		if err := loadConfig(configPath, cfg, options); err != nil {
+			options.logger.Error("failed to reload configuration", "path", configPath, "error", err)
+			return
+		}
+		apply(cfg)
+
+		options.logger.Info("configuration reloaded", "path", configPath)
+	})
+}
+
+// newLoadOptions applies opts to the default load options
+func newLoadOptions(opts []Option) *loadOptions {
+	options := &loadOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.logger == nil {
+		options.logger = slog.Default()
+	}
+	return options
+}
+
+// decodeConfig maps the configuration read by v to the struct and validates it
+func decodeConfig(v *viper.Viper, configStruct interface{}, options *loadOptions) error {
+	if t := reflect.TypeOf(configStruct); t == nil || t.Kind() != reflect.Ptr {
+		return fmt.Errorf("failed to map configuration to struct: %T is not a pointer", configStruct)
+	}
+
+	// Defaults given to WithDefaults take precedence over those of struct tags
+	setTagDefaults(v, reflect.TypeOf(configStruct))
+	setDefaults(v, "", options.defaults)
+	if options.env {
+		bindEnv(v, reflect.TypeOf(configStruct), options)
+	}
+	for path, value := range options.flags {
+		v.Set(path, value)
+	}
+
+	if errs := checkKeys(v, reflect.TypeOf(configStruct), options); len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
 
-		fmt.Println("configuration reloaded")
+	// Errors of user hooks are recorded to be returned as they are
+	hookErrs := &hookErrors{}
+	var hooks []mapstructure.DecodeHookFunc
+	for _, hook := range options.decodeHooks {
+		hooks = append(hooks, hookErrs.wrap(hook))
+	}
+	hooks = append(hooks,
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+	)
+
+	err := v.Unmarshal(configStruct, func(c *mapstructure.DecoderConfig) {
+		c.DecodeHook = mapstructure.ComposeDecodeHookFunc(hooks...)
 	})
+	if err != nil {
+		return fmt.Errorf("failed to map configuration to struct: %w", decodeErrors(err, hookErrs))
+	}
+	if options.provenance != nil {
+		recordProvenance(v, reflect.TypeOf(configStruct), options)
+	}
+
+	return validateConfig(configStruct)
+}
+
+// setDefaults registers default values, flattening nested maps into dotted key paths
+func setDefaults(v *viper.Viper, path string, defaults map[string]interface{}) {
+	for key, value := range defaults {
+		if nested, ok := value.(map[string]interface{}); ok {
+			setDefaults(v, joinPath(path, key), nested)
+			continue
+		}
+		v.SetDefault(joinPath(path, key), value)
+	}
+}
+
+// readConfig creates a Viper instance and reads the configuration into it.
+// Defaults are registered by decodeConfig, which knows the struct
+func readConfig(configPath string, options *loadOptions) (*viper.Viper, error) {
+	v := viper.New()
+
+	// Read in-memory configuration
+	if options.configData != nil {
+		v.SetConfigType(options.configType)
+		if err := v.ReadConfig(bytes.NewReader(options.configData)); err != nil {
+			return nil, newParseError("", options.configData, err)
+		}
+		return v, nil
+	}
+
+	// Read and merge the configuration files
+	merged, err := readLayers(configLayers(configPath, options), options)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.MergeConfigMap(merged); err != nil {
+		return nil, fmt.Errorf("failed to read configuration file: %w", err)
+	}
 
-	return nil
+	return v, nil
 }
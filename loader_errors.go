@@ -0,0 +1,159 @@
+package easycfg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+var (
+	// parserMessageRegexp matches the prefix of YAML and Viper errors, repeating the position
+	parserMessageRegexp = regexp.MustCompile(`^(While parsing config: )?(yaml: )?(line \d+: )?`)
+
+	// errorLineRegexp matches the line number of a YAML error
+	errorLineRegexp = regexp.MustCompile(`line (\d+)`)
+
+	// Errors of mapstructure, which only reports them as text
+	expectedTypeRegexp  = regexp.MustCompile(`^'([^']*)' expected type '([^']*)', got (?:unconvertible type )?'([^']*)'(?:, value: '(.*)')?$`)
+	cannotParseRegexp   = regexp.MustCompile(`^cannot parse '([^']*)' as (\w+): (.*)$`)
+	errorDecodingRegexp = regexp.MustCompile(`^error decoding '([^']*)': (.*)$`)
+	namedErrorRegexp    = regexp.MustCompile(`^'([^']*)':? (.*)$`)
+)
+
+// newParseError returns a *ParseError locating err, an error of the parser
+// for the configuration data, from the positions the YAML, JSON and TOML
+// parsers report
+func newParseError(file string, data []byte, err error) *ParseError {
+	parseErr := &ParseError{File: file, Err: err}
+
+	var syntaxErr *json.SyntaxError
+	var positionErr interface{ Position() (int, int) }
+	switch {
+	case errors.As(err, &syntaxErr):
+		parseErr.Line, parseErr.Column = offsetPosition(data, syntaxErr.Offset)
+	case errors.As(err, &positionErr):
+		parseErr.Line, parseErr.Column = positionErr.Position()
+	default:
+		if match := errorLineRegexp.FindStringSubmatch(err.Error()); match != nil {
+			parseErr.Line, _ = strconv.Atoi(match[1])
+		}
+	}
+
+	lines := strings.Split(string(data), "\n")
+	if parseErr.Line < 1 || parseErr.Line > len(lines) {
+		return parseErr
+	}
+	line := strings.TrimRight(lines[parseErr.Line-1], "\r")
+	if strings.TrimSpace(line) == "" {
+		return parseErr
+	}
+
+	// The caret keeps the tabs of the line, and points at its text if the column is unknown
+	indent := len(line) - len(strings.TrimLeft(line, " \t"))
+	if parseErr.Column > 0 {
+		indent = min(len([]rune(line)), parseErr.Column-1)
+	}
+	var caret strings.Builder
+	for _, r := range []rune(line)[:indent] {
+		if r == '\t' {
+			caret.WriteRune('\t')
+		} else {
+			caret.WriteRune(' ')
+		}
+	}
+	caret.WriteRune('^')
+	parseErr.Snippet = fmt.Sprintf("  %s\n  %s", line, caret.String())
+	return parseErr
+}
+
+// offsetPosition converts a byte offset in data into a line and column
+func offsetPosition(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := string(data[:offset])
+	line := strings.Count(before, "\n") + 1
+	column := len([]rune(before[strings.LastIndex(before, "\n")+1:]))
+	if column == 0 {
+		column = 1
+	}
+	return line, column
+}
+
+// hookErrors records the errors returned by decode hooks, which mapstructure
+// only reports as text
+type hookErrors struct {
+	errs []error
+}
+
+// wrap returns a decode hook running hook and recording its errors
+func (h *hookErrors) wrap(hook mapstructure.DecodeHookFunc) mapstructure.DecodeHookFuncValue {
+	return func(from, to reflect.Value) (interface{}, error) {
+		value, err := mapstructure.DecodeHookExec(hook, from, to)
+		if err != nil {
+			h.errs = append(h.errs, err)
+		}
+		return value, err
+	}
+}
+
+// take removes and returns the first recorded error with the given message,
+// or nil if there is none
+func (h *hookErrors) take(message string) error {
+	for i, err := range h.errs {
+		if err.Error() == message {
+			h.errs = append(h.errs[:i], h.errs[i+1:]...)
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeErrors converts the errors of mapstructure into *DecodeError values,
+// joined with errors.Join if there are several. Errors returned by the hooks
+// recorded in hookErrs are kept as the Err of their *DecodeError
+func decodeErrors(err error, hookErrs *hookErrors) error {
+	var mapErr *mapstructure.Error
+	if !errors.As(err, &mapErr) {
+		if match := errorDecodingRegexp.FindStringSubmatch(err.Error()); match != nil {
+			if hookErr := hookErrs.take(match[2]); hookErr != nil {
+				return &DecodeError{Path: match[1], Err: hookErr}
+			}
+		}
+		return &DecodeError{Err: err}
+	}
+	errs := make([]error, len(mapErr.Errors))
+	for i, message := range mapErr.Errors {
+		decodeErr := newDecodeError(message)
+		if decodeErr.Err != nil {
+			if hookErr := hookErrs.take(decodeErr.Err.Error()); hookErr != nil {
+				decodeErr.Err = hookErr
+			}
+		}
+		errs[i] = decodeErr
+	}
+	return errors.Join(errs...)
+}
+
+// newDecodeError parses an error message of mapstructure
+func newDecodeError(message string) *DecodeError {
+	if match := expectedTypeRegexp.FindStringSubmatch(message); match != nil {
+		return &DecodeError{Path: match[1], Expected: match[2], Actual: match[3], Value: match[4]}
+	}
+	if match := cannotParseRegexp.FindStringSubmatch(message); match != nil {
+		return &DecodeError{Path: match[1], Expected: match[2], Actual: "string", Err: errors.New(match[3])}
+	}
+	if match := errorDecodingRegexp.FindStringSubmatch(message); match != nil {
+		return &DecodeError{Path: match[1], Err: errors.New(match[2])}
+	}
+	if match := namedErrorRegexp.FindStringSubmatch(message); match != nil {
+		return &DecodeError{Path: match[1], Err: errors.New(match[2])}
+	}
+	return &DecodeError{Err: errors.New(message)}
+}
@@ -0,0 +1,114 @@
+package easycfg
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigErrors(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"syntax.yml":  "server:\n  host: localhost\n   port: 8080\n",
+		"syntax.toml": "[server]\nport = = 8080\n",
+		"syntax.json": "{\n  \"server\": {\"port\": }\n}\n",
+		"types.yml":   "server:\n  host: [a]\n  port: abc\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	// Missing files match both ErrConfigNotFound and fs.ErrNotExist
+	err := LoadConfig(filepath.Join(tempDir, "missing.yml"), &TestConfig{})
+	if !errors.Is(err, ErrConfigNotFound) || !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected ErrConfigNotFound, got %v", err)
+	}
+	if _, err := FindConfig("missing", WithSearchPaths(tempDir)); !errors.Is(err, ErrConfigNotFound) {
+		t.Errorf("Expected ErrConfigNotFound from FindConfig, got %v", err)
+	}
+
+	// Parse errors locate the error in the file
+	tests := []struct {
+		name    string
+		line    int
+		column  int
+		snippet string
+	}{
+		{"syntax.yml", 3, 0, "     port: 8080\n     ^"},
+		{"syntax.toml", 2, 8, "  port = = 8080\n         ^"},
+		{"syntax.json", 2, 22, "    \"server\": {\"port\": }\n                       ^"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(tempDir, tt.name)
+		err := LoadConfig(path, &TestConfig{})
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%s: expected *ParseError, got %v", tt.name, err)
+			continue
+		}
+		if parseErr.File != path || parseErr.Line != tt.line || parseErr.Column != tt.column {
+			t.Errorf("%s: position = %s:%d:%d, expected %s:%d:%d", tt.name, parseErr.File, parseErr.Line, parseErr.Column, path, tt.line, tt.column)
+		}
+		if parseErr.Snippet != tt.snippet {
+			t.Errorf("%s: snippet = %q, expected %q", tt.name, parseErr.Snippet, tt.snippet)
+		}
+	}
+
+	err = LoadConfig("", &TestConfig{}, WithConfigData([]byte("server: [1\n"), "yaml"))
+	if !strings.HasPrefix(err.Error(), "failed to parse configuration data at line 1: ") {
+		t.Errorf("Unexpected error for configuration data: %v", err)
+	}
+
+	// Decode errors name the key and the types
+	err = LoadConfig(filepath.Join(tempDir, "types.yml"), &TestConfig{})
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("Expected *DecodeError, got %v", err)
+	}
+	if decodeErr.Path != "server.host" || decodeErr.Expected != "string" || decodeErr.Actual != "[]interface {}" {
+		t.Errorf("Unexpected decode error: %+v", decodeErr)
+	}
+	if !strings.Contains(err.Error(), `server.port: expected int, got string: strconv.ParseInt: parsing "abc": invalid syntax`) {
+		t.Errorf("Missing decode error for server.port: %v", err)
+	}
+
+	// Errors of decode hooks keep their chain
+	errPort := errors.New("port is reserved")
+	hook := func(from, to reflect.Type, data interface{}) (interface{}, error) {
+		if to.Kind() == reflect.Int && data == "reserved" {
+			return nil, fmt.Errorf("invalid port: %w", errPort)
+		}
+		return data, nil
+	}
+	err = LoadConfig("", &TestConfig{}, WithConfigData([]byte("server:\n  port: reserved\n"), "yaml"), WithDecodeHook(hook))
+	if !errors.Is(err, errPort) || !errors.As(err, &decodeErr) || decodeErr.Path != "server.port" {
+		t.Errorf("Expected *DecodeError for server.port wrapping the hook error, got %v", err)
+	}
+	rootHook := func(from, to reflect.Type, data interface{}) (interface{}, error) {
+		if to == reflect.TypeOf(TestConfig{}) {
+			return nil, errPort
+		}
+		return data, nil
+	}
+	err = LoadConfig("", &TestConfig{}, WithConfigData([]byte("server:\n  port: 80\n"), "yaml"), WithDecodeHook(rootHook))
+	if !errors.Is(err, errPort) {
+		t.Errorf("Expected the hook error of the root, got %v", err)
+	}
+
+	// Validation errors are matched through the aggregated error
+	type requiredConfig struct {
+		Name string `mapstructure:"name" validate:"required"`
+	}
+	err = LoadConfig("", &requiredConfig{}, WithConfigData([]byte("other: 1\n"), "yaml"), WithRequiredKeys())
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || !errors.Is(err, ErrMissingKey) {
+		t.Errorf("Expected *ValidationError with ErrMissingKey, got %v", err)
+	}
+}
@@ -1,9 +1,13 @@
 package easycfg
 
 import (
+	"errors"
+	"net"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
+	"testing/fstest"
 	"time"
 )
 
@@ -77,6 +81,351 @@ logging:
 	}
 }
 
+func TestLoadConfigWithConfigData(t *testing.T) {
+	yamlContent := `
+server:
+  host: embedded
+  port: 7070
+`
+	cfg := &TestConfig{}
+
+	// The path is ignored when configuration data is given
+	err := LoadConfig("", cfg, WithConfigData([]byte(yamlContent), "yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if cfg.Server.Host != "embedded" {
+		t.Errorf("cfg.Server.Host = %q, expected \"embedded\"", cfg.Server.Host)
+	}
+	if cfg.Server.Port != 7070 {
+		t.Errorf("cfg.Server.Port = %d, expected 7070", cfg.Server.Port)
+	}
+}
+
+func TestLoadConfigOptions(t *testing.T) {
+	yamlContent := `
+server:
+  host: localhost
+database:
+  url: mysql://localhost:3306/testdb
+`
+	fsys := fstest.MapFS{"config/app.yml": &fstest.MapFile{Data: []byte(yamlContent)}}
+	t.Setenv("APP_SERVER_HOST", "envhost")
+
+	// Files are read from fsys, defaults fill missing keys and the environment overrides the file
+	cfg := &TestConfig{}
+	err := LoadConfig("config/app.yml", cfg,
+		WithFS(fsys),
+		WithDefaults(map[string]interface{}{
+			"server":        map[string]interface{}{"port": 8080},
+			"logging.level": "info",
+		}),
+		WithEnvPrefix("APP"),
+	)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Server.Host != "envhost" {
+		t.Errorf("cfg.Server.Host = %q, expected \"envhost\"", cfg.Server.Host)
+	}
+	if cfg.Server.Port != 8080 {
+		t.Errorf("cfg.Server.Port = %d, expected 8080", cfg.Server.Port)
+	}
+	if cfg.Logging.Level != "info" {
+		t.Errorf("cfg.Logging.Level = %q, expected \"info\"", cfg.Logging.Level)
+	}
+	if cfg.Database.URL != "mysql://localhost:3306/testdb" {
+		t.Errorf("cfg.Database.URL = %q, expected the file value", cfg.Database.URL)
+	}
+
+	// Strict mode rejects keys without a field
+	strictYaml := "server:\n  hots: localhost\n"
+	if err := LoadConfig("", &TestConfig{}, WithConfigData([]byte(strictYaml), "yaml")); err != nil {
+		t.Errorf("Unexpected error without strict mode: %v", err)
+	}
+	if err := LoadConfig("", &TestConfig{}, WithConfigData([]byte(strictYaml), "yaml"), WithStrict()); err == nil {
+		t.Errorf("Expected error for unknown key in strict mode")
+	}
+
+	// Decode hooks convert values into custom field types, after the default hooks
+	type hookConfig struct {
+		Addr    net.IP        `mapstructure:"addr"`
+		Timeout time.Duration `mapstructure:"timeout"`
+		Tags    []string      `mapstructure:"tags"`
+	}
+	hook := func(from, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.String || to != reflect.TypeOf(net.IP{}) {
+			return data, nil
+		}
+		return net.ParseIP(data.(string)), nil
+	}
+	hc := &hookConfig{}
+	hookYaml := "addr: 10.0.0.1\ntimeout: 5s\ntags: a,b\n"
+	if err := LoadConfig("", hc, WithConfigData([]byte(hookYaml), "yaml"), WithDecodeHook(hook)); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if !hc.Addr.Equal(net.ParseIP("10.0.0.1")) || hc.Timeout != 5*time.Second || len(hc.Tags) != 2 {
+		t.Errorf("Unexpected decoded values: %+v", hc)
+	}
+}
+
+func TestLoadConfigEnv(t *testing.T) {
+	type envConfig struct {
+		General struct {
+			Server struct {
+				Port     int      `mapstructure:"port"`
+				HTTPPort int      `mapstructure:"http-port"`
+				Hosts    []string `mapstructure:"hosts"`
+				Weights  []int    `mapstructure:"weights"`
+			} `mapstructure:"server"`
+		} `mapstructure:"general"`
+		Name   string            `mapstructure:"name"`
+		Labels map[string]string `mapstructure:"labels"`
+	}
+	yamlContent := []byte("general:\n  server:\n    port: 8080\nname: file\n")
+
+	// Keys missing from the file are read as well, and lists are comma-separated
+	t.Setenv("GENERAL_SERVER_PORT", "9999")
+	t.Setenv("GENERAL_SERVER_HTTP_PORT", "80")
+	t.Setenv("GENERAL_SERVER_HOSTS", "a,b,c")
+	t.Setenv("GENERAL_SERVER_WEIGHTS", "1,2")
+	cfg := &envConfig{}
+	if err := LoadConfig("", cfg, WithConfigData(yamlContent, "yaml"), WithEnv()); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.General.Server.Port != 9999 || cfg.General.Server.HTTPPort != 80 {
+		t.Errorf("Ports = %d, %d, expected 9999, 80", cfg.General.Server.Port, cfg.General.Server.HTTPPort)
+	}
+	if !reflect.DeepEqual(cfg.General.Server.Hosts, []string{"a", "b", "c"}) || !reflect.DeepEqual(cfg.General.Server.Weights, []int{1, 2}) {
+		t.Errorf("Lists = %v, %v, expected [a b c], [1 2]", cfg.General.Server.Hosts, cfg.General.Server.Weights)
+	}
+	if cfg.Name != "file" {
+		t.Errorf("cfg.Name = %q, expected \"file\"", cfg.Name)
+	}
+
+	// Prefixes and separators select other names
+	t.Setenv("APP__GENERAL__SERVER__PORT", "7777")
+	t.Setenv("APP__NAME", "env")
+	cfg = &envConfig{}
+	if err := LoadConfig("", cfg, WithConfigData(yamlContent, "yaml"), WithEnvPrefix("app"), WithEnvSeparator("__")); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.General.Server.Port != 7777 || cfg.Name != "env" {
+		t.Errorf("cfg = %+v, expected port 7777 and name env", cfg)
+	}
+
+	// Without an env option the environment is ignored
+	cfg = &envConfig{}
+	if err := LoadConfig("", cfg, WithConfigData(yamlContent, "yaml")); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.General.Server.Port != 8080 {
+		t.Errorf("cfg.General.Server.Port = %d, expected 8080", cfg.General.Server.Port)
+	}
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	type defaultsConfig struct {
+		Server struct {
+			Port    int           `mapstructure:"port" default:"8080"`
+			Timeout time.Duration `mapstructure:"timeout" default:"5s"`
+			Hosts   []string      `mapstructure:"hosts" default:"a,b"`
+			Debug   bool          `mapstructure:"debug" default:"true"`
+		} `mapstructure:"server"`
+		Name  string `mapstructure:"name" default:"app"`
+		Level string `mapstructure:"level" default:"info"`
+		Owner string `mapstructure:"owner"`
+	}
+
+	// Defaults apply to absent keys only, so zero values in the file are kept
+	t.Setenv("LEVEL", "warn")
+	yamlContent := []byte("server:\n  port: 0\n  debug: false\n")
+	cfg := &defaultsConfig{}
+	var provenance Provenance
+	err := LoadConfig("", cfg, WithConfigData(yamlContent, "yaml"), WithEnv(),
+		WithDefaults(map[string]interface{}{"name": "override"}),
+		WithProvenance(&provenance),
+	)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Server.Port != 0 || cfg.Server.Debug || cfg.Server.Timeout != 5*time.Second {
+		t.Errorf("cfg.Server = %+v, expected port 0, no debug and a 5s timeout", cfg.Server)
+	}
+	if !reflect.DeepEqual(cfg.Server.Hosts, []string{"a", "b"}) {
+		t.Errorf("cfg.Server.Hosts = %v, expected [a b]", cfg.Server.Hosts)
+	}
+	if cfg.Name != "override" || cfg.Level != "warn" {
+		t.Errorf("cfg.Name, cfg.Level = %q, %q, expected override, warn", cfg.Name, cfg.Level)
+	}
+
+	expected := Provenance{
+		"server.port":    SourceFile,
+		"server.debug":   SourceFile,
+		"server.timeout": SourceDefault,
+		"server.hosts":   SourceDefault,
+		"name":           SourceDefault,
+		"level":          SourceEnv,
+	}
+	if !reflect.DeepEqual(provenance, expected) {
+		t.Errorf("Provenance = %v, expected %v", provenance, expected)
+	}
+}
+
+// recursiveNode is a self-referential configuration struct
+type recursiveNode struct {
+	Name string         `mapstructure:"name" default:"root"`
+	Next *recursiveNode `mapstructure:"next"`
+}
+
+func TestLoadConfigRecursiveType(t *testing.T) {
+	cfg := &recursiveNode{}
+	if err := LoadConfig("", cfg, WithConfigData([]byte("next:\n  name: a\n"), "yaml")); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Name != "root" || cfg.Next == nil || cfg.Next.Name != "a" {
+		t.Errorf("Unexpected configuration: %+v", cfg)
+	}
+}
+
+func TestLoadConfigEnvNested(t *testing.T) {
+	type level8 struct {
+		Value string `mapstructure:"value"`
+		L     struct {
+			Value string `mapstructure:"value"`
+		} `mapstructure:"l"`
+	}
+	type level struct {
+		Value string `mapstructure:"value"`
+		L     struct {
+			L struct {
+				L struct {
+					L struct {
+						L struct {
+							L struct {
+								L level8 `mapstructure:"l"`
+							} `mapstructure:"l"`
+						} `mapstructure:"l"`
+					} `mapstructure:"l"`
+				} `mapstructure:"l"`
+			} `mapstructure:"l"`
+		} `mapstructure:"l"`
+	}
+
+	// Self-referential types are bound once, and deeply nested keys are bound
+	t.Setenv("NAME", "env")
+	t.Setenv("L_L_L_L_L_L_L_VALUE", "eight")
+	t.Setenv("L_L_L_L_L_L_L_L_VALUE", "nine")
+	node := &recursiveNode{}
+	if err := LoadConfig("", node, WithConfigData([]byte("next:\n  name: file\n"), "yaml"), WithEnv()); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if node.Name != "env" || node.Next == nil || node.Next.Name != "file" {
+		t.Errorf("Unexpected configuration: %+v", node)
+	}
+	cfg := &level{}
+	if err := LoadConfig("", cfg, WithConfigData([]byte("value: file\n"), "yaml"), WithEnv()); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.L.L.L.L.L.L.L.Value != "eight" || cfg.L.L.L.L.L.L.L.L.Value != "nine" {
+		t.Errorf("Nested values = %q, %q, expected eight and nine", cfg.L.L.L.L.L.L.L.Value, cfg.L.L.L.L.L.L.L.L.Value)
+	}
+}
+
+func TestLoadConfigNumericTypes(t *testing.T) {
+	yamlContent := `
+id: 9007199254740993
+max: 18446744073709551615
+mode: 0o644
+mask: 0xFFFFFFFF
+price: 0.1
+total: "12345678901234567.89"
+`
+	var cfg struct {
+		ID    int64   `mapstructure:"id"`
+		Max   uint64  `mapstructure:"max"`
+		Mode  uint32  `mapstructure:"mode"`
+		Mask  uint32  `mapstructure:"mask"`
+		Price Decimal `mapstructure:"price"`
+		Total Decimal `mapstructure:"total"`
+	}
+
+	if err := LoadConfig("", &cfg, WithConfigData([]byte(yamlContent), "yaml")); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if cfg.ID != 9007199254740993 {
+		t.Errorf("cfg.ID = %d, expected 9007199254740993", cfg.ID)
+	}
+	if cfg.Max != 18446744073709551615 {
+		t.Errorf("cfg.Max = %d, expected 18446744073709551615", cfg.Max)
+	}
+	if cfg.Mode != 0644 {
+		t.Errorf("cfg.Mode = %o, expected 644", cfg.Mode)
+	}
+	if cfg.Mask != 0xFFFFFFFF {
+		t.Errorf("cfg.Mask = %x, expected ffffffff", cfg.Mask)
+	}
+	if cfg.Price != "0.1" {
+		t.Errorf("cfg.Price = %q, expected \"0.1\"", cfg.Price)
+	}
+	if cfg.Total != "12345678901234567.89" {
+		t.Errorf("cfg.Total = %q, expected \"12345678901234567.89\"", cfg.Total)
+	}
+}
+
+// Test enum type, as generated for an enum directive
+type testLevel string
+
+func (v testLevel) IsValid() bool {
+	return v == "debug" || v == "info"
+}
+
+func (v testLevel) EnumValues() []string {
+	return []string{"debug", "info"}
+}
+
+func TestLoadConfigEnumValidation(t *testing.T) {
+	yamlContent := `
+logging:
+  level: trace
+  outputs: [debug, verbose]
+`
+	tempDir := t.TempDir()
+	yamlPath := filepath.Join(tempDir, "config.yml")
+	if err := os.WriteFile(yamlPath, []byte(yamlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test YAML file: %v", err)
+	}
+
+	cfg := &struct {
+		Logging struct {
+			Level   testLevel   `mapstructure:"level"`
+			Outputs []testLevel `mapstructure:"outputs"`
+		} `mapstructure:"logging"`
+	}{}
+
+	err := LoadConfig(yamlPath, cfg)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("LoadConfig error = %v, expected a *ValidationError", err)
+	}
+
+	expectedPaths := []string{"logging.level", "logging.outputs[1]"}
+	if len(validationErr.Errors) != len(expectedPaths) {
+		t.Fatalf("ValidationError has %d errors, expected %d: %v", len(validationErr.Errors), len(expectedPaths), err)
+	}
+	for i, path := range expectedPaths {
+		if validationErr.Errors[i].Path != path {
+			t.Errorf("Errors[%d].Path = %q, expected %q", i, validationErr.Errors[i].Path, path)
+		}
+		var enumErr *EnumError
+		if !errors.As(validationErr.Errors[i], &enumErr) {
+			t.Errorf("Errors[%d] = %v, expected an *EnumError", i, validationErr.Errors[i])
+		}
+	}
+}
+
 func TestWatchConfig(t *testing.T) {
 	// Create test YAML file
 	yamlContent := `
@@ -0,0 +1,146 @@
+package easycfg
+
+// Model is the intermediate representation of a YAML file that YamlToStruct
+// passes to its output template. Custom templates given with WithTemplate
+// receive a *Model as their data
+//
+// With WithSplitFiles the template is executed once per file: the Model of
+// the main file holds only the root struct, and the Model of each section
+// file has Section set and holds the structs and enums declared under that
+// top-level key, with key constants and the loader disabled
+type Model struct {
+	Package string       // Go package name of the generated file
+	Source  string       // File name of the source YAML
+	Section string       // Top-level key rendered in this file, empty for the main file
+	Root    *StructDef   // Root struct, named after the YAML file
+	Structs []*StructDef // Structs rendered in this file, the root first and nested structs in document order
+	Enums   []*EnumDef   // Enum types declared with enum directives
+	Keys    []*KeyDef    // Every leaf key path that can be addressed without indexing a list
+	Imports []string     // Import specs required by the default template, empty entries separate groups
+	Options ModelOptions // Generator options enabled with GenerateOption values
+}
+
+// ModelOptions reports which optional outputs were requested from the generator
+type ModelOptions struct {
+	CompareMethods bool // WithCompareMethods
+	KeyConstants   bool // WithKeyConstants
+	Getters        bool // WithGetters
+	Loader         bool // WithLoader or WithEmbeddedDefault
+	EmbedDefault   bool // WithEmbeddedDefault
+}
+
+// StructDef describes a struct inferred from a YAML mapping
+type StructDef struct {
+	Name    string      // Go type name
+	Path    string      // Dotted key path of the mapping, empty for the root struct
+	Comment string      // Comment of the mapping's key in the source YAML
+	IsRoot  bool        // Whether this is the root struct
+	Fields  []*FieldDef // Fields in document order
+
+	// Reserved lists removed fields whose Protocol Buffers numbers and names
+	// must not be reused, set with LanguageProto
+	Reserved []*ReservedField
+}
+
+// ReservedField is a field removed from a struct after its Protocol Buffers
+// number was recorded in the lock file
+type ReservedField struct {
+	Number int    // Field number
+	Name   string // Protocol Buffers field name
+}
+
+// FieldDef describes a struct field inferred from a YAML key
+type FieldDef struct {
+	Name       string            // Go field name
+	Key        string            // YAML key
+	Path       string            // Dotted key path from the root
+	Type       *TypeRef          // Inferred Go type
+	Tag        string            // Struct tag, without the enclosing backquotes
+	Comment    string            // Comment of the key in the source YAML, without directives
+	Sample     string            // Sample value from the source YAML, empty for mappings
+	Directives map[string]string // easycfg directives from comments and the mapping file
+	Number     int               // Protocol Buffers field number, set with LanguageProto
+}
+
+// TypeKind classifies an inferred Go type
+type TypeKind int
+
+const (
+	KindScalar TypeKind = iota // string, numbers, bool, easycfg.Secret, easycfg.Decimal and enums
+	KindStruct                 // a generated struct
+	KindSlice                  // a slice of Elem
+	KindAny                    // interface{}, for null values and empty lists
+	KindMap                    // a map from string keys to Elem, for mappings marked with a map directive
+)
+
+// String returns the lower case name of the kind
+func (k TypeKind) String() string {
+	switch k {
+	case KindStruct:
+		return "struct"
+	case KindSlice:
+		return "slice"
+	case KindAny:
+		return "any"
+	case KindMap:
+		return "map"
+	default:
+		return "scalar"
+	}
+}
+
+// TypeRef describes the Go type inferred for a YAML value
+type TypeRef struct {
+	Kind TypeKind
+	Name string   // Scalar type or struct name
+	Elem *TypeRef // Element type of a slice or value type of a map
+}
+
+// GoType returns the Go type expression for the reference
+func (t *TypeRef) GoType() string {
+	switch t.Kind {
+	case KindSlice:
+		return "[]" + t.Elem.GoType()
+	case KindMap:
+		return "map[string]" + t.Elem.GoType()
+	case KindAny:
+		return "interface{}"
+	default:
+		return t.Name
+	}
+}
+
+// IsScalar reports whether the type is a scalar
+func (t *TypeRef) IsScalar() bool { return t.Kind == KindScalar }
+
+// IsStruct reports whether the type is a generated struct
+func (t *TypeRef) IsStruct() bool { return t.Kind == KindStruct }
+
+// IsSlice reports whether the type is a slice
+func (t *TypeRef) IsSlice() bool { return t.Kind == KindSlice }
+
+// IsAny reports whether the type is interface{}
+func (t *TypeRef) IsAny() bool { return t.Kind == KindAny }
+
+// IsMap reports whether the type is a map
+func (t *TypeRef) IsMap() bool { return t.Kind == KindMap }
+
+// EnumDef describes an enum type declared with an enum directive
+type EnumDef struct {
+	Name   string       // Go type name
+	Path   string       // Dotted key path of the annotated key
+	Values []*EnumValue // Allowed values in declaration order
+}
+
+// EnumValue is one allowed value of an enum
+type EnumValue struct {
+	Const  string // Go constant name
+	Value  string // The allowed value
+	Number int    // Protocol Buffers enum value number, set with LanguageProto
+}
+
+// KeyDef is the dotted key path of a leaf value
+type KeyDef struct {
+	Const string // Go constant name, such as KeyGeneralServerPort
+	Path  string // Dotted key path, such as general.server.port
+}
@@ -0,0 +1,309 @@
+package easycfg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectFile is the name of the project file FindProject looks for
+const ProjectFile = ".easycfg.yml"
+
+// Project lists the generation targets of a project file, such as:
+//
+//	parallel: 4
+//	defaults:
+//	  compare: true
+//	  getters: true
+//	targets:
+//	  - yaml: services/api/config.yml
+//	    output: services/api/config
+//	  - yaml: services/jobs/*.yml
+//	    output: services/jobs/config
+//	    package: jobconfig
+//	    lang: ts
+//
+// The options under defaults apply to every target unless the target sets them
+type Project struct {
+	Dir      string           // Directory of the project file, which target paths are relative to
+	Parallel int              // Number of files generated at once, the number of CPUs if zero
+	Targets  []*ProjectTarget // Targets in file order
+}
+
+// ProjectTarget holds a YAML file, or a glob or directory of YAML files, and
+// the options its code is generated with. The fields match the CLI flags
+type ProjectTarget struct {
+	Yaml     string `yaml:"yaml"`     // YAML file, glob pattern or directory of *.yml and *.yaml files
+	Output   string `yaml:"output"`   // Output directory, generated if empty
+	Package  string `yaml:"package"`  // Package name, the name of the output directory if empty
+	Lang     string `yaml:"lang"`     // Language of the generated code, go if empty
+	Compare  bool   `yaml:"compare"`  // See WithCompareMethods
+	Keys     bool   `yaml:"keys"`     // See WithKeyConstants
+	Getters  bool   `yaml:"getters"`  // See WithGetters
+	Loader   bool   `yaml:"loader"`   // See WithLoader
+	Embed    bool   `yaml:"embed"`    // See WithEmbeddedDefault
+	Secrets  bool   `yaml:"secrets"`  // See WithSecretDetection
+	Decimal  bool   `yaml:"decimal"`  // See WithDecimalFloats
+	Mapping  string `yaml:"mapping"`  // See WithMappingFile
+	Template string `yaml:"template"` // See WithTemplate
+	Scaffold bool   `yaml:"scaffold"` // See WithScaffold
+	Split    bool   `yaml:"split"`    // See WithSplitFiles
+}
+
+// projectFile is the layout of a project file
+type projectFile struct {
+	Parallel int             `yaml:"parallel"`
+	Defaults ProjectTarget   `yaml:"defaults"`
+	Targets  []ProjectTarget `yaml:"targets"`
+}
+
+// FindProject returns the path of the project file in dir or its closest parent directory
+func FindProject(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to find project file: %v", err)
+	}
+	for {
+		path := filepath.Join(dir, ProjectFile)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("failed to find project file: no %s in %s or its parents", ProjectFile, dir)
+		}
+		dir = parent
+	}
+}
+
+// LoadProject reads a project file. Unknown options are rejected, so that
+// misspelled options do not silently generate different code
+func LoadProject(path string) (*Project, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project file: %v", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var file projectFile
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse project file: %v", err)
+	}
+
+	// Decoding each target over a copy of the defaults keeps the options it does not set
+	var raw struct {
+		Defaults yaml.Node   `yaml:"defaults"`
+		Targets  []yaml.Node `yaml:"targets"`
+	}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse project file: %v", err)
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project file: %v", err)
+	}
+	project := &Project{Dir: dir, Parallel: file.Parallel}
+	for i := range raw.Targets {
+		target := file.Defaults
+		if err := raw.Targets[i].Decode(&target); err != nil {
+			return nil, fmt.Errorf("failed to parse project file: %v", err)
+		}
+		if target.Yaml == "" {
+			return nil, fmt.Errorf("failed to parse project file: target %d has no yaml", i+1)
+		}
+		project.Targets = append(project.Targets, &target)
+	}
+	return project, nil
+}
+
+// Generate runs YamlToStruct for every YAML file of the targets whose output
+// directory is dir, or of every target if dir is empty, generating up to
+// Parallel files at once. Running it from the package directory, as go
+// generate does, lets a single directive per package regenerate its configs:
+//
+//	//go:generate go run github.com/chiayu0816/easycfg/cmd/easycfgcli generate
+func (p *Project) Generate(dir string) error {
+	return p.run(dir, "generate", YamlToStruct)
+}
+
+// Check runs CheckGenerated for the same files as Generate. It returns an
+// *OutdatedError listing the out of date files of every target if any is out of date
+func (p *Project) Check(dir string) error {
+	err := p.run(dir, "check", CheckGenerated)
+
+	// Outdated files of several targets are reported as one error
+	var projectErr *ProjectError
+	if !errors.As(err, &projectErr) {
+		return err
+	}
+	merged := &OutdatedError{}
+	for _, targetErr := range projectErr.Errors {
+		var outdated *OutdatedError
+		if !errors.As(targetErr.Err, &outdated) {
+			return err
+		}
+		merged.Paths = append(merged.Paths, outdated.Paths...)
+		merged.Diff += outdated.Diff
+	}
+	return merged
+}
+
+// projectJob is a YAML file to generate code from
+type projectJob struct {
+	yamlPath string
+	target   *ProjectTarget
+}
+
+// run calls fn for every YAML file of the targets in dir, see Generate
+func (p *Project) run(dir, action string, fn func(yamlFilePath, outputDir, packageName string, opts ...GenerateOption) error) error {
+	if dir != "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return fmt.Errorf("failed to %s project: %v", action, err)
+		}
+		dir = abs
+	}
+
+	var jobs []projectJob
+	for _, target := range p.Targets {
+		if dir != "" && filepath.Clean(p.path(target.output())) != dir {
+			continue
+		}
+		files, err := p.files(target)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			jobs = append(jobs, projectJob{yamlPath: file, target: target})
+		}
+	}
+	if len(jobs) == 0 {
+		if dir != "" {
+			return fmt.Errorf("failed to %s project: no target has output %s", action, dir)
+		}
+		return fmt.Errorf("failed to %s project: no targets", action)
+	}
+
+	parallel := p.Parallel
+	if parallel <= 0 {
+		parallel = runtime.NumCPU()
+	}
+	errs := make([]error, len(jobs))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, job projectJob) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs[i] = fn(job.yamlPath, p.path(job.target.output()), job.target.packageName(), p.options(job.target)...)
+		}(i, job)
+	}
+	wg.Wait()
+
+	projectErr := &ProjectError{Action: action}
+	for i, err := range errs {
+		if err != nil {
+			projectErr.Errors = append(projectErr.Errors, &TargetError{Path: jobs[i].yamlPath, Err: err})
+		}
+	}
+	if len(projectErr.Errors) > 0 {
+		return projectErr
+	}
+	return nil
+}
+
+// files returns the YAML files of a target in lexical order
+func (p *Project) files(target *ProjectTarget) ([]string, error) {
+	pattern := p.path(target.Yaml)
+	if info, err := os.Stat(pattern); err == nil && info.IsDir() {
+		var files []string
+		for _, ext := range []string{"*.yml", "*.yaml"} {
+			matches, err := filepath.Glob(filepath.Join(pattern, ext))
+			if err != nil {
+				return nil, fmt.Errorf("failed to list YAML files: %v", err)
+			}
+			files = append(files, matches...)
+		}
+		sort.Strings(files)
+		if len(files) == 0 {
+			return nil, fmt.Errorf("failed to list YAML files: no *.yml or *.yaml files in %s", target.Yaml)
+		}
+		return files, nil
+	}
+
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list YAML files: %v", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("failed to list YAML files: no files match %s", target.Yaml)
+	}
+	return files, nil
+}
+
+// path resolves a path of the project file against its directory
+func (p *Project) path(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(p.Dir, path)
+}
+
+// options returns the generator options of a target
+func (p *Project) options(t *ProjectTarget) []GenerateOption {
+	lang := LanguageGo
+	if t.Lang != "" {
+		lang = Language(t.Lang)
+	}
+	opts := []GenerateOption{WithLanguage(lang)}
+	flags := []struct {
+		set bool
+		opt GenerateOption
+	}{
+		{t.Compare, WithCompareMethods()},
+		{t.Keys, WithKeyConstants()},
+		{t.Getters, WithGetters()},
+		{t.Loader, WithLoader()},
+		{t.Embed, WithEmbeddedDefault()},
+		{t.Secrets, WithSecretDetection()},
+		{t.Decimal, WithDecimalFloats()},
+		{t.Mapping != "", WithMappingFile(p.path(t.Mapping))},
+		{t.Template != "", WithTemplate(p.path(t.Template))},
+		{t.Scaffold, WithScaffold()},
+		{t.Split, WithSplitFiles()},
+	}
+	for _, flag := range flags {
+		if flag.set {
+			opts = append(opts, flag.opt)
+		}
+	}
+	return opts
+}
+
+// output returns the output directory of a target
+func (t *ProjectTarget) output() string {
+	if t.Output == "" {
+		return "generated"
+	}
+	return t.Output
+}
+
+// packageName returns the package name of a target
+func (t *ProjectTarget) packageName() string {
+	if t.Package != "" {
+		return t.Package
+	}
+	return strings.ReplaceAll(filepath.Base(t.output()), "-", "")
+}
@@ -0,0 +1,119 @@
+package easycfg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProject(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"services/api/config.yml": "server:\n  port: 8080\n",
+		"services/jobs/a.yml":     "batch: 10\n",
+		"services/jobs/b.yaml":    "retries: 3\n",
+		ProjectFile: `
+parallel: 2
+defaults:
+  getters: true
+targets:
+  - yaml: services/api/config.yml
+    output: services/api/config
+    compare: true
+  - yaml: services/jobs
+    output: services/jobs/conf
+    package: jobs
+    getters: false
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	// The project file is found from any directory below it
+	projectPath, err := FindProject(filepath.Join(tempDir, "services", "jobs"))
+	if err != nil {
+		t.Fatalf("FindProject failed: %v", err)
+	}
+	project, err := LoadProject(projectPath)
+	if err != nil {
+		t.Fatalf("LoadProject failed: %v", err)
+	}
+	if len(project.Targets) != 2 || !project.Targets[0].Getters || project.Targets[1].Getters {
+		t.Fatalf("Defaults were not merged into targets: %+v", project.Targets)
+	}
+
+	if err := project.Generate(""); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	apiCode, err := os.ReadFile(filepath.Join(tempDir, "services", "api", "config", "config.go"))
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+	for _, expected := range []string{"package config", "func (c *Server) GetPort() int", "func (c *Config) Equal("} {
+		if !strings.Contains(string(apiCode), expected) {
+			t.Errorf("Generated file is missing expected content: %s", expected)
+		}
+	}
+	for _, name := range []string{"a.go", "b.go"} {
+		code, err := os.ReadFile(filepath.Join(tempDir, "services", "jobs", "conf", name))
+		if err != nil {
+			t.Fatalf("Failed to read generated file: %v", err)
+		}
+		if !strings.Contains(string(code), "package jobs") || strings.Contains(string(code), "func (c *") {
+			t.Errorf("Unexpected generated file %s:\n%s", name, code)
+		}
+	}
+	if err := project.Check(""); err != nil {
+		t.Errorf("Expected generated files to be up to date: %v", err)
+	}
+
+	// Only the targets of a directory are checked and generated, as with go generate
+	apiYaml := filepath.Join(tempDir, "services", "api", "config.yml")
+	if err := os.WriteFile(apiYaml, []byte("server:\n  port: 8080\n  host: x\n"), 0644); err != nil {
+		t.Fatalf("Failed to update test YAML file: %v", err)
+	}
+	if err := project.Check(filepath.Join(tempDir, "services", "jobs", "conf")); err != nil {
+		t.Errorf("Expected jobs files to be up to date: %v", err)
+	}
+	var outdated *OutdatedError
+	if err := project.Check(""); !errors.As(err, &outdated) || len(outdated.Paths) != 1 {
+		t.Errorf("Expected an OutdatedError for the API config, got: %v", err)
+	}
+	if err := project.Generate(filepath.Join(tempDir, "services", "api", "config")); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if err := project.Check(""); err != nil {
+		t.Errorf("Expected generated files to be up to date: %v", err)
+	}
+
+	// Failures are reported per YAML file
+	if err := os.WriteFile(filepath.Join(tempDir, "services", "jobs", "a.yml"), []byte("batch: [\n"), 0644); err != nil {
+		t.Fatalf("Failed to update test YAML file: %v", err)
+	}
+	var projectErr *ProjectError
+	if err := project.Generate(""); !errors.As(err, &projectErr) || len(projectErr.Errors) != 1 || !strings.HasSuffix(projectErr.Errors[0].Path, "a.yml") {
+		t.Errorf("Expected a ProjectError for a.yml, got: %v", err)
+	}
+	var targetErr *TargetError
+	if err := project.Generate(""); !errors.As(err, &targetErr) || !strings.HasSuffix(targetErr.Path, "a.yml") {
+		t.Errorf("Expected a TargetError for a.yml, got: %v", err)
+	}
+
+	// Misspelled options are rejected
+	badPath := filepath.Join(tempDir, "bad.yml")
+	if err := os.WriteFile(badPath, []byte("targets:\n  - yaml: a.yml\n    outptu: x\n"), 0644); err != nil {
+		t.Fatalf("Failed to create project file: %v", err)
+	}
+	if _, err := LoadProject(badPath); err == nil {
+		t.Errorf("Expected error for unknown project option")
+	}
+}
@@ -0,0 +1,64 @@
+package easycfg
+
+import (
+	"os"
+	"reflect"
+
+	"github.com/spf13/viper"
+)
+
+// Source tells where the value of a configuration key came from
+type Source string
+
+// Sources of configuration values, from the lowest to the highest precedence
+const (
+	SourceDefault Source = "default" // A default tag or WithDefaults
+	SourceFile    Source = "file"    // The configuration files or WithConfigData
+	SourceEnv     Source = "env"     // An environment variable bound with WithEnv
+	SourceFlag    Source = "flag"    // A command-line flag applied with WithFlags
+)
+
+// Provenance maps the dotted key path of every field holding a single value
+// to the source of its value. Keys absent from every source are not listed,
+// telling them apart from keys set to the zero value
+type Provenance map[string]Source
+
+// WithProvenance makes LoadConfig and WatchConfig record in p where the value
+// of every field came from, replacing its contents on each reload
+func WithProvenance(p *Provenance) Option {
+	return func(o *loadOptions) {
+		o.provenance = p
+	}
+}
+
+// recordProvenance stores the source of every field of a struct type in options.provenance
+func recordProvenance(v *viper.Viper, t reflect.Type, options *loadOptions) {
+	provenance := Provenance{}
+	walkLeaves(t, reflect.Value{}, "", func(path string, field reflect.StructField, value reflect.Value) {
+		if source, ok := valueSource(v, path, options); ok {
+			provenance[path] = source
+		}
+	})
+	*options.provenance = provenance
+}
+
+// valueSource returns the source of the value of a key, and false if no source sets it
+func valueSource(v *viper.Viper, path string, options *loadOptions) (Source, bool) {
+	separator := options.envSeparator
+	if separator == "" {
+		separator = defaultEnvSeparator
+	}
+
+	_, isFlag := options.flags[path]
+	switch {
+	case isFlag:
+		return SourceFlag, true
+	case options.env && os.Getenv(envVarName(options.envPrefix, separator, path)) != "":
+		return SourceEnv, true
+	case v.InConfig(path):
+		return SourceFile, true
+	case v.IsSet(path):
+		return SourceDefault, true
+	}
+	return "", false
+}
@@ -0,0 +1,58 @@
+package easycfg
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// redacted is printed in place of the value of a Secret
+const redacted = "[REDACTED]"
+
+// Secret is a string configuration value, such as a password or token, that
+// is redacted whenever it is printed or marshaled. LoadConfig decodes into it
+// like a plain string; use Reveal to read the actual value
+type Secret string
+
+// Reveal returns the actual secret value
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+// String returns a redacted placeholder instead of the secret value
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// GoString returns the redacted placeholder for the %#v verb
+func (s Secret) GoString() string {
+	return fmt.Sprintf("%q", s.String())
+}
+
+// Format redacts the secret for every fmt verb
+func (s Secret) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'q':
+		fmt.Fprintf(f, "%q", s.String())
+	case 'v':
+		if f.Flag('#') {
+			fmt.Fprint(f, s.GoString())
+			return
+		}
+		fmt.Fprint(f, s.String())
+	default:
+		fmt.Fprint(f, s.String())
+	}
+}
+
+// MarshalJSON encodes the redacted placeholder instead of the secret value
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// MarshalYAML encodes the redacted placeholder instead of the secret value
+func (s Secret) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
@@ -0,0 +1,83 @@
+package easycfg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestSecretRedaction(t *testing.T) {
+	cfg := struct {
+		User     string
+		Password Secret
+	}{User: "admin", Password: "hunter2"}
+
+	// Every fmt verb must redact the value
+	for _, format := range []string{"%v", "%+v", "%#v", "%s", "%q", "%x"} {
+		output := fmt.Sprintf(format, cfg)
+		if strings.Contains(output, "hunter2") {
+			t.Errorf("fmt.Sprintf(%q) leaked the secret: %s", format, output)
+		}
+	}
+	if s := cfg.Password.String(); s != "[REDACTED]" {
+		t.Errorf("Secret.String() = %q, expected \"[REDACTED]\"", s)
+	}
+
+	jsonData, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	if strings.Contains(string(jsonData), "hunter2") {
+		t.Errorf("json.Marshal leaked the secret: %s", jsonData)
+	}
+
+	yamlData, err := yaml.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("yaml.Marshal failed: %v", err)
+	}
+	if strings.Contains(string(yamlData), "hunter2") {
+		t.Errorf("yaml.Marshal leaked the secret: %s", yamlData)
+	}
+
+	if cfg.Password.Reveal() != "hunter2" {
+		t.Errorf("Secret.Reveal() = %q, expected \"hunter2\"", cfg.Password.Reveal())
+	}
+
+	// Unset secrets print as empty so missing values remain visible
+	var empty Secret
+	if empty.String() != "" {
+		t.Errorf("empty Secret.String() = %q, expected \"\"", empty.String())
+	}
+}
+
+func TestLoadConfigSecret(t *testing.T) {
+	yamlContent := `
+redis:
+  addrs: ["localhost:6379"]
+  password: password123
+`
+	tempDir := t.TempDir()
+	yamlPath := filepath.Join(tempDir, "config.yml")
+	if err := os.WriteFile(yamlPath, []byte(yamlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test YAML file: %v", err)
+	}
+
+	cfg := &struct {
+		Redis struct {
+			Addrs    []string `mapstructure:"addrs"`
+			Password Secret   `mapstructure:"password"`
+		} `mapstructure:"redis"`
+	}{}
+	if err := LoadConfig(yamlPath, cfg); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if cfg.Redis.Password.Reveal() != "password123" {
+		t.Errorf("cfg.Redis.Password.Reveal() = %q, expected \"password123\"", cfg.Redis.Password.Reveal())
+	}
+}
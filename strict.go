@@ -0,0 +1,222 @@
+package easycfg
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// ErrMissingKey is the error of a FieldError reporting a required key that no
+// source sets, see WithRequiredKeys
+var ErrMissingKey = errors.New("required key is missing")
+
+// UnknownKeyError reports a configuration key that matches no struct field
+type UnknownKeyError struct {
+	Suggestion string // Closest key of the struct, empty if none is close
+}
+
+// Error suggests the closest key, if any
+func (e *UnknownKeyError) Error() string {
+	if e.Suggestion != "" {
+		return fmt.Sprintf("unknown key, did you mean %s?", e.Suggestion)
+	}
+	return "unknown key"
+}
+
+// WithStrictWarn is WithStrict that logs every unknown key as a warning
+// instead of failing
+func WithStrictWarn() Option {
+	return func(o *loadOptions) {
+		o.strictWarn = true
+	}
+}
+
+// WithRequiredKeys makes LoadConfig and WatchConfig fail on fields whose
+// validate tag has the required rule if their key is missing from the
+// configuration, environment and flags. Unlike the required rule, which
+// rejects zero values, it accepts keys that are set to the zero value
+func WithRequiredKeys() Option {
+	return func(o *loadOptions) {
+		o.requiredKeys = true
+	}
+}
+
+// checkKeys returns the unknown keys of the configuration, if WithStrict is
+// set, and the missing required keys, if WithRequiredKeys is set. With
+// WithStrictWarn, unknown keys are logged instead
+func checkKeys(v *viper.Viper, t reflect.Type, options *loadOptions) []*FieldError {
+	var errs []*FieldError
+	if options.strict || options.strictWarn {
+		unknown := unknownKeys(t, v.AllSettings(), "")
+		if options.strict {
+			errs = append(errs, unknown...)
+		} else {
+			for _, fieldErr := range unknown {
+				options.logger.Warn("unknown configuration key", "key", fieldErr.Path, "error", fieldErr.Err)
+			}
+		}
+	}
+	if options.requiredKeys {
+		walkLeaves(t, reflect.Value{}, "", func(path string, field reflect.StructField, value reflect.Value) {
+			if !hasRequiredRule(field.Tag.Get("validate")) {
+				return
+			}
+			if source, ok := valueSource(v, path, options); !ok || source == SourceDefault {
+				errs = append(errs, &FieldError{Path: path, Err: ErrMissingKey})
+			}
+		})
+	}
+	return errs
+}
+
+// hasRequiredRule reports whether a validate tag has the required rule for the field itself
+func hasRequiredRule(tag string) bool {
+	for _, rule := range strings.Split(tag, ",") {
+		switch strings.TrimSpace(rule) {
+		case "required":
+			return true
+		case "dive":
+			return false
+		}
+	}
+	return false
+}
+
+// unknownKeys returns an error for every key of value, as decoded by Viper,
+// that the type it is decoded into does not have, descending into nested
+// structs, maps and lists
+func unknownKeys(t reflect.Type, value interface{}, path string) []*FieldError {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var errs []*FieldError
+	switch t.Kind() {
+	case reflect.Struct:
+		settings, ok := value.(map[string]interface{})
+		if !ok {
+			// Structs such as time.Time are decoded from scalars by hooks
+			return nil
+		}
+		keys, remain := structKeys(t)
+		if remain {
+			return nil
+		}
+		for _, key := range sortedKeys(settings) {
+			keyPath := joinPath(path, key)
+			fieldType, ok := keys[key]
+			if !ok {
+				errs = append(errs, &FieldError{Path: keyPath, Value: settings[key], Err: &UnknownKeyError{Suggestion: suggestKey(key, keys)}})
+				continue
+			}
+			errs = append(errs, unknownKeys(fieldType, settings[key], keyPath)...)
+		}
+	case reflect.Map:
+		settings, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for _, key := range sortedKeys(settings) {
+			errs = append(errs, unknownKeys(t.Elem(), settings[key], joinPath(path, key))...)
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil
+		}
+		for i, item := range items {
+			errs = append(errs, unknownKeys(t.Elem(), item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	return errs
+}
+
+// structKeys returns the lower case keys of the fields of a struct type,
+// including those of squashed structs, and whether a field collects the
+// remaining keys with the mapstructure remain option
+func structKeys(t reflect.Type) (map[string]reflect.Type, bool) {
+	return structKeysOnce(t, map[reflect.Type]bool{})
+}
+
+// structKeysOnce is structKeys skipping the squashed struct types in onPath,
+// whose keys are being collected by its callers
+func structKeysOnce(t reflect.Type, onPath map[reflect.Type]bool) (map[string]reflect.Type, bool) {
+	keys := map[string]reflect.Type{}
+	if onPath[t] {
+		return keys, false
+	}
+	onPath[t] = true
+	defer delete(onPath, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		if strings.Contains(field.Tag.Get("mapstructure"), ",remain") {
+			return nil, true
+		}
+		key, squash := fieldKey(field)
+		if key == "-" {
+			continue
+		}
+		if squash && isStructType(field.Type) {
+			ft := field.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			nested, remain := structKeysOnce(ft, onPath)
+			if remain {
+				return nil, true
+			}
+			for key, fieldType := range nested {
+				keys[key] = fieldType
+			}
+			continue
+		}
+		keys[strings.ToLower(key)] = field.Type
+	}
+	return keys, false
+}
+
+// suggestKey returns the known key closest to key by edit distance, or an
+// empty string if none is close enough to be a likely misspelling
+func suggestKey(key string, known map[string]reflect.Type) string {
+	best, bestDistance := "", len(key)/3+2
+	for _, candidate := range sortedKeys(known) {
+		if distance := editDistance(key, candidate); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between two strings, counting
+// a swap of adjacent characters as a single edit
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	rows := make([][]int, len(ra)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(rb)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(ra)][len(rb)]
+}
@@ -0,0 +1,112 @@
+package easycfg
+
+import (
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// strictConfig is a configuration struct for strict mode
+type strictConfig struct {
+	Logger struct {
+		Level  string `mapstructure:"level" validate:"required"`
+		Format string `mapstructure:"format"`
+	} `mapstructure:"logger"`
+	Upstreams []struct {
+		URL string `mapstructure:"url"`
+	} `mapstructure:"upstreams"`
+	Labels map[string]string `mapstructure:"labels"`
+	Port   int               `mapstructure:"port" validate:"required"`
+}
+
+func TestLoadConfigStrict(t *testing.T) {
+	yamlContent := []byte(`
+logger:
+  levle: debug
+  format: json
+upstreams:
+  - url: https://example.com
+    timout: 5s
+labels:
+  anything: goes
+port: 8080
+verbose: true
+`)
+
+	err := LoadConfig("", &strictConfig{}, WithConfigData(yamlContent, "yaml"), WithStrict())
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected *ValidationError, got %v", err)
+	}
+	expected := []string{
+		"logger.levle: unknown key, did you mean level?",
+		"upstreams[0].timout: unknown key",
+		"verbose: unknown key",
+	}
+	if len(validationErr.Errors) != len(expected) {
+		t.Fatalf("Got %d errors, expected %d: %v", len(validationErr.Errors), len(expected), err)
+	}
+	for i, message := range expected {
+		if validationErr.Errors[i].Error() != message {
+			t.Errorf("Errors[%d] = %q, expected %q", i, validationErr.Errors[i].Error(), message)
+		}
+	}
+
+	// Warnings are logged without failing
+	logs := make(logWriter, 10)
+	err = LoadConfig("", &strictConfig{}, WithConfigData(yamlContent, "yaml"), WithStrictWarn(), WithLogger(slog.New(slog.NewTextHandler(logs, nil))))
+	if err == nil || !strings.Contains(err.Error(), "logger.level: is required") {
+		t.Errorf("Expected only the validation error, got %v", err)
+	}
+	if len(logs) != len(expected) {
+		t.Errorf("Logged %d warnings, expected %d", len(logs), len(expected))
+	}
+	if line := <-logs; !strings.Contains(line, "key=logger.levle") {
+		t.Errorf("Unexpected warning: %s", line)
+	}
+}
+
+func TestLoadConfigRequiredKeys(t *testing.T) {
+	// A required key set to the zero value is present, unlike a missing one
+	yamlContent := []byte("logger:\n  level: debug\nport: 0\n")
+	err := LoadConfig("", &strictConfig{}, WithConfigData(yamlContent, "yaml"), WithRequiredKeys())
+	if err == nil || !strings.Contains(err.Error(), "port: is required") {
+		t.Errorf("Expected the required rule to reject port 0, got %v", err)
+	}
+
+	err = LoadConfig("", &strictConfig{}, WithConfigData([]byte("port: 80\n"), "yaml"), WithRequiredKeys(),
+		WithDefaults(map[string]interface{}{"logger.level": "info"}))
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Errors) != 1 {
+		t.Fatalf("Expected one missing key, got %v", err)
+	}
+	if fieldErr := validationErr.Errors[0]; fieldErr.Path != "logger.level" || !errors.Is(fieldErr, ErrMissingKey) {
+		t.Errorf("Errors[0] = %v, expected logger.level to be missing", fieldErr)
+	}
+
+	t.Setenv("LOGGER_LEVEL", "warn")
+	if err := LoadConfig("", &strictConfig{}, WithConfigData([]byte("port: 80\n"), "yaml"), WithRequiredKeys(), WithEnv()); err != nil {
+		t.Errorf("LoadConfig failed: %v", err)
+	}
+}
+
+// RecursiveSquash is a struct squashing a pointer to its own type
+type RecursiveSquash struct {
+	Name             string `mapstructure:"name"`
+	*RecursiveSquash `mapstructure:",squash"`
+}
+
+func TestLoadConfigStrictRecursiveType(t *testing.T) {
+	yamlContent := []byte("name: root\nnext:\n  name: a\n  nxet: 1\n")
+	err := LoadConfig("", &recursiveNode{}, WithConfigData(yamlContent, "yaml"), WithStrict(), WithRequiredKeys())
+	if err == nil || !strings.Contains(err.Error(), "next.nxet: unknown key, did you mean next?") {
+		t.Errorf("Expected unknown key next.nxet, got %v", err)
+	}
+
+	keys, remain := structKeys(reflect.TypeOf(RecursiveSquash{}))
+	if remain || len(keys) != 1 || keys["name"] == nil {
+		t.Errorf("structKeys() = %v, %v, expected only name", keys, remain)
+	}
+}
@@ -0,0 +1,67 @@
+package easycfg
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Enum is implemented by generated enum types, letting LoadConfig reject values
+// outside the allowed set
+type Enum interface {
+	IsValid() bool
+	EnumValues() []string
+}
+
+// validateConfig checks the decoded configuration against its enum types and
+// validate tags, and returns a *ValidationError listing every invalid value
+func validateConfig(configStruct interface{}) error {
+	var errs []*FieldError
+	walkFields(reflect.ValueOf(configStruct), "", func(path string, field reflect.StructField, value reflect.Value) {
+		errs = append(errs, validateEnums(path, value)...)
+		if tag, ok := field.Tag.Lookup("validate"); ok && tag != "-" {
+			errs = append(errs, validateTag(path, tag, value)...)
+		}
+	})
+
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+// validateEnums checks that an enum value, or every enum in a slice or map, is allowed
+func validateEnums(path string, value reflect.Value) []*FieldError {
+	if value.Kind() == reflect.Slice || value.Kind() == reflect.Array {
+		var errs []*FieldError
+		for i := 0; i < value.Len(); i++ {
+			errs = append(errs, validateEnums(fmt.Sprintf("%s[%d]", path, i), value.Index(i))...)
+		}
+		return errs
+	}
+	if value.Kind() == reflect.Map {
+		var errs []*FieldError
+		for _, key := range sortedMapKeys(value) {
+			errs = append(errs, validateEnums(joinPath(path, fmt.Sprint(key.Interface())), value.MapIndex(key))...)
+		}
+		return errs
+	}
+
+	if !value.CanInterface() {
+		return nil
+	}
+	enum, ok := value.Interface().(Enum)
+	if !ok || enum.IsValid() {
+		return nil
+	}
+
+	// An empty value means the key is absent, which is not an enum violation
+	str := fmt.Sprint(value.Interface())
+	if str == "" {
+		return nil
+	}
+	return []*FieldError{{
+		Path:  path,
+		Value: value.Interface(),
+		Err:   &EnumError{Value: str, Allowed: enum.EnumValues()},
+	}}
+}
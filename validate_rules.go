@@ -0,0 +1,318 @@
+package easycfg
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// RuleError reports a value violating a rule of the validate tag of its field
+type RuleError struct {
+	Rule   string // Name of the rule, such as min
+	Param  string // Parameter of the rule, such as 1 for min=1
+	length bool   // Whether the rule applies to the length of the value
+}
+
+// Error describes the rule the value violates
+func (e *RuleError) Error() string {
+	size := ""
+	if e.length {
+		size = " in length"
+	}
+	switch e.Rule {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		return fmt.Sprintf("must be at least %s%s", e.Param, size)
+	case "max", "lte":
+		return fmt.Sprintf("must be at most %s%s", e.Param, size)
+	case "gt":
+		return fmt.Sprintf("must be greater than %s%s", e.Param, size)
+	case "lt":
+		return fmt.Sprintf("must be less than %s%s", e.Param, size)
+	case "len":
+		return fmt.Sprintf("must be exactly %s in length", e.Param)
+	case "eq":
+		return fmt.Sprintf("must be %s", e.Param)
+	case "ne":
+		return fmt.Sprintf("must not be %s", e.Param)
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.Join(oneofValues(e.Param), ", "))
+	default:
+		return fmt.Sprintf("must be a valid %s", e.Rule)
+	}
+}
+
+var (
+	// oneofRegexp matches the values of a oneof rule, which are single-quoted if they hold spaces
+	oneofRegexp = regexp.MustCompile(`'[^']*'|\S+`)
+
+	// hostnameRegexp matches RFC 1123 host names
+	hostnameRegexp = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*\.?$`)
+
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// formatRules check string values against the formats of validate tags
+var formatRules = map[string]func(s string) bool{
+	"email": func(s string) bool {
+		addr, err := mail.ParseAddress(s)
+		return err == nil && addr.Address == s
+	},
+	"url": func(s string) bool {
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != "" && (u.Host != "" || u.Opaque != "")
+	},
+	"hostname": hostnameRegexp.MatchString,
+	"ip": func(s string) bool {
+		return net.ParseIP(s) != nil
+	},
+	"ipv4": func(s string) bool {
+		ip := net.ParseIP(s)
+		return ip != nil && ip.To4() != nil
+	},
+	"ipv6": func(s string) bool {
+		ip := net.ParseIP(s)
+		return ip != nil && ip.To4() == nil
+	},
+	"cidr": func(s string) bool {
+		_, _, err := net.ParseCIDR(s)
+		return err == nil
+	},
+	"hostname_port": func(s string) bool {
+		host, port, err := net.SplitHostPort(s)
+		if err != nil {
+			return false
+		}
+		n, err := strconv.ParseUint(port, 10, 16)
+		return err == nil && n > 0 && (host == "" || hostnameRegexp.MatchString(host) || net.ParseIP(host) != nil)
+	},
+}
+
+// validateTag checks a field value against the comma-separated rules of its
+// validate tag, following the syntax of github.com/go-playground/validator:
+// required, omitempty, min, max, len, eq, ne, gt, gte, lt, lte, oneof, the
+// formats of formatRules and dive, which applies the rules after it to every
+// element of a list or map
+func validateTag(path, tag string, value reflect.Value) []*FieldError {
+	rules := strings.Split(tag, ",")
+	for i, rule := range rules {
+		if rule == "dive" {
+			return validateDive(path, strings.Join(rules[i+1:], ","), rules[:i], value)
+		}
+	}
+	if err := checkRules(rules, value); err != nil {
+		return []*FieldError{{Path: path, Value: fieldValue(value), Err: err}}
+	}
+	return nil
+}
+
+// validateDive checks the rules before dive against a list or map and the rules after it against its elements
+func validateDive(path, elemTag string, rules []string, value reflect.Value) []*FieldError {
+	if err := checkRules(rules, value); err != nil {
+		return []*FieldError{{Path: path, Value: fieldValue(value), Err: err}}
+	}
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+
+	var errs []*FieldError
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			errs = append(errs, validateTag(fmt.Sprintf("%s[%d]", path, i), elemTag, value.Index(i))...)
+		}
+	case reflect.Map:
+		for _, key := range sortedMapKeys(value) {
+			errs = append(errs, validateTag(joinPath(path, fmt.Sprint(key.Interface())), elemTag, value.MapIndex(key))...)
+		}
+	default:
+		errs = append(errs, &FieldError{Path: path, Value: fieldValue(value), Err: fmt.Errorf("dive requires a list or map, not %s", value.Kind())})
+	}
+	return errs
+}
+
+// checkRules returns the error of the first rule the value violates
+func checkRules(rules []string, value reflect.Value) error {
+	// Nil pointers only violate required, and empty values skip all rules after omitempty
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			for _, rule := range rules {
+				if rule == "required" {
+					return &RuleError{Rule: rule}
+				}
+			}
+			return nil
+		}
+		value = value.Elem()
+	}
+
+	for _, rule := range rules {
+		rule = strings.TrimSpace(rule)
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "":
+			continue
+		case "omitempty":
+			if value.IsZero() {
+				return nil
+			}
+		case "required":
+			if isEmptyValue(value) {
+				return &RuleError{Rule: name}
+			}
+		case "oneof":
+			if !isOneOf(value, param) {
+				return &RuleError{Rule: name, Param: param}
+			}
+		case "min", "max", "len", "eq", "ne", "gt", "gte", "lt", "lte":
+			ok, length, err := compareRule(value, name, param)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return &RuleError{Rule: name, Param: param, length: length}
+			}
+		default:
+			check, ok := formatRules[name]
+			if !ok {
+				return fmt.Errorf("unknown validation rule %q", name)
+			}
+			if value.Kind() != reflect.String {
+				return fmt.Errorf("rule %s requires a string, not %s", name, value.Kind())
+			}
+			if !check(value.String()) {
+				return &RuleError{Rule: name}
+			}
+		}
+	}
+	return nil
+}
+
+// isEmptyValue reports whether a value is missing for the required rule:
+// nil lists and maps, and zero values of other types
+func isEmptyValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map:
+		return value.IsNil()
+	default:
+		return value.IsZero()
+	}
+}
+
+// isOneOf reports whether the text of a value is one of the space-separated values of param
+func isOneOf(value reflect.Value, param string) bool {
+	text := fmt.Sprint(value.Interface())
+	if value.Kind() == reflect.String {
+		text = value.String()
+	}
+	for _, allowed := range oneofValues(param) {
+		if text == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// oneofValues splits the parameter of a oneof rule into its values
+func oneofValues(param string) []string {
+	values := oneofRegexp.FindAllString(param, -1)
+	for i, value := range values {
+		values[i] = strings.Trim(value, "'")
+	}
+	return values
+}
+
+// compareRule compares a value with the parameter of a comparison rule. Strings,
+// lists and maps are compared by their length, except for eq and ne on strings,
+// and durations take parameters such as 1s. It also reports whether the length was compared
+func compareRule(value reflect.Value, rule, param string) (ok bool, length bool, err error) {
+	var actual, limit float64
+	switch kind := value.Kind(); {
+	case value.Type() == durationType:
+		d, err := time.ParseDuration(param)
+		if err != nil {
+			return false, false, fmt.Errorf("invalid duration %q in rule %s: %v", param, rule, err)
+		}
+		actual, limit = float64(value.Int()), float64(d)
+	case value.Type() == decimalValue:
+		if actual, err = strconv.ParseFloat(value.String(), 64); err != nil {
+			return false, false, fmt.Errorf("invalid decimal %q: %v", value.String(), err)
+		}
+		limit, err = numberParam(rule, param)
+	case kind == reflect.String && (rule == "eq" || rule == "ne"):
+		return (value.String() == param) == (rule == "eq"), false, nil
+	case kind == reflect.Bool:
+		b, err := strconv.ParseBool(param)
+		if err != nil || (rule != "eq" && rule != "ne") {
+			return false, false, fmt.Errorf("rule %s=%s does not apply to a bool", rule, param)
+		}
+		return (value.Bool() == b) == (rule == "eq"), false, nil
+	case kind == reflect.String, kind == reflect.Slice, kind == reflect.Array, kind == reflect.Map:
+		length = true
+		if kind == reflect.String {
+			actual = float64(utf8.RuneCountInString(value.String()))
+		} else {
+			actual = float64(value.Len())
+		}
+		n, err := strconv.Atoi(param)
+		if err != nil {
+			return false, false, fmt.Errorf("invalid length %q in rule %s: %v", param, rule, err)
+		}
+		limit = float64(n)
+	case kind >= reflect.Int && kind <= reflect.Int64:
+		actual = float64(value.Int())
+		limit, err = numberParam(rule, param)
+	case kind >= reflect.Uint && kind <= reflect.Uintptr:
+		actual = float64(value.Uint())
+		limit, err = numberParam(rule, param)
+	case kind == reflect.Float32 || kind == reflect.Float64:
+		actual = value.Float()
+		limit, err = numberParam(rule, param)
+	default:
+		return false, false, fmt.Errorf("rule %s does not apply to %s", rule, value.Type())
+	}
+	if err != nil {
+		return false, false, err
+	}
+
+	switch rule {
+	case "min", "gte":
+		ok = actual >= limit
+	case "max", "lte":
+		ok = actual <= limit
+	case "gt":
+		ok = actual > limit
+	case "lt":
+		ok = actual < limit
+	case "len", "eq":
+		ok = actual == limit
+	case "ne":
+		ok = actual != limit
+	}
+	return ok, length, nil
+}
+
+// numberParam parses the number parameter of a comparison rule
+func numberParam(rule, param string) (float64, error) {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q in rule %s: %v", param, rule, err)
+	}
+	return limit, nil
+}
+
+// fieldValue returns the interface value of a field for a FieldError, or nil if it has none
+func fieldValue(value reflect.Value) interface{} {
+	if !value.IsValid() || !value.CanInterface() {
+		return nil
+	}
+	return value.Interface()
+}
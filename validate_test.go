@@ -0,0 +1,144 @@
+package easycfg
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// validatedConfig is a configuration struct with validate tags
+type validatedConfig struct {
+	Server struct {
+		Host    string        `mapstructure:"host" validate:"required,hostname"`
+		Port    int           `mapstructure:"port" validate:"required,min=1,max=65535"`
+		Timeout time.Duration `mapstructure:"timeout" validate:"omitempty,gte=1s"`
+	} `mapstructure:"server"`
+	Level     string   `mapstructure:"level" validate:"oneof=debug info 'very verbose'"`
+	Addrs     []string `mapstructure:"addrs" validate:"min=1,dive,hostname_port"`
+	Admin     string   `mapstructure:"admin" validate:"omitempty,email"`
+	Price     Decimal  `mapstructure:"price" validate:"gt=0"`
+	Upstreams []struct {
+		URL string `mapstructure:"url" validate:"url"`
+	} `mapstructure:"upstreams"`
+}
+
+func TestValidateTags(t *testing.T) {
+	valid := `
+server:
+  host: api.example.com
+  port: 8080
+level: very verbose
+addrs: ["localhost:80", "10.0.0.1:443"]
+price: "0.50"
+upstreams:
+  - url: https://example.com
+`
+	if err := LoadConfig("", &validatedConfig{}, WithConfigData([]byte(valid), "yaml")); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	invalid := `
+server:
+  port: 70000
+  timeout: 10ms
+level: trace
+addrs: ["localhost", "localhost:8080"]
+admin: nobody
+price: "-1"
+upstreams:
+  - url: https://example.com
+  - url: not a url
+`
+	err := LoadConfig("", &validatedConfig{}, WithConfigData([]byte(invalid), "yaml"))
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected *ValidationError, got %v", err)
+	}
+	expected := []string{
+		"server.host: is required",
+		"server.port: must be at most 65535",
+		"server.timeout: must be at least 1s",
+		"level: must be one of: debug, info, very verbose",
+		"addrs[0]: must be a valid hostname_port",
+		"admin: must be a valid email",
+		"price: must be greater than 0",
+		"upstreams[1].url: must be a valid url",
+	}
+	if len(validationErr.Errors) != len(expected) {
+		t.Fatalf("Got %d errors, expected %d: %v", len(validationErr.Errors), len(expected), err)
+	}
+	for i, message := range expected {
+		if validationErr.Errors[i].Error() != message {
+			t.Errorf("Errors[%d] = %q, expected %q", i, validationErr.Errors[i].Error(), message)
+		}
+		var ruleErr *RuleError
+		if !errors.As(validationErr.Errors[i], &ruleErr) {
+			t.Errorf("Errors[%d] = %v, expected a *RuleError", i, validationErr.Errors[i])
+		}
+	}
+
+	// Lengths are compared for strings and lists
+	type lengthConfig struct {
+		Name string   `mapstructure:"name" validate:"min=3"`
+		Tags []string `mapstructure:"tags" validate:"required,len=2"`
+	}
+	err = LoadConfig("", &lengthConfig{}, WithConfigData([]byte("name: ab\n"), "yaml"))
+	if err == nil || !strings.Contains(err.Error(), "name: must be at least 3 in length") || !strings.Contains(err.Error(), "tags: is required") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Unknown rules are reported rather than ignored
+	type unknownConfig struct {
+		Name string `mapstructure:"name" validate:"requird"`
+	}
+	err = LoadConfig("", &unknownConfig{}, WithConfigData([]byte("name: app\n"), "yaml"))
+	if err == nil || !strings.Contains(err.Error(), `unknown validation rule "requird"`) {
+		t.Errorf("Expected unknown rule error, got %v", err)
+	}
+}
+
+func TestWatchConfigValidation(t *testing.T) {
+	yamlPath := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(yamlPath, []byte("server:\n  host: localhost\n  port: 8080\naddrs: [\"localhost:80\"]\nprice: \"1\"\nlevel: info\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test YAML file: %v", err)
+	}
+
+	logs := make(logWriter, 10)
+	logger := slog.New(slog.NewTextHandler(logs, nil))
+	cfg := &validatedConfig{}
+	changed := make(chan struct{}, 10)
+	if err := WatchConfig(yamlPath, cfg, func() { changed <- struct{}{} }, WithLogger(logger)); err != nil {
+		t.Fatalf("WatchConfig failed: %v", err)
+	}
+
+	// An invalid reload is logged and keeps the current configuration
+	if err := os.WriteFile(yamlPath, []byte("server:\n  host: localhost\n  port: 0\naddrs: [\"localhost:80\"]\nprice: \"1\"\nlevel: info\n"), 0644); err != nil {
+		t.Fatalf("Failed to update test YAML file: %v", err)
+	}
+	select {
+	case <-changed:
+		t.Fatalf("Invalid configuration was applied: %+v", cfg)
+	case line := <-logs:
+		if !strings.Contains(line, "server.port: is required") {
+			t.Errorf("Unexpected log: %s", line)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Timed out waiting for the reload to fail")
+	}
+	if cfg.Server.Port != 8080 {
+		t.Errorf("cfg.Server.Port = %d, expected 8080", cfg.Server.Port)
+	}
+}
+
+// logWriter sends every log line written to it to the channel
+type logWriter chan string
+
+// Write sends p as a log line
+func (w logWriter) Write(p []byte) (int, error) {
+	w <- string(p)
+	return len(p), nil
+}
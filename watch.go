@@ -0,0 +1,87 @@
+package easycfg
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDelay is how long file events must settle before a change is reported,
+// so that a file written in several steps is only read once it is complete
+const watchDelay = 50 * time.Millisecond
+
+// watchFiles calls onChange whenever any of the files is written, created,
+// removed or replaced, including through a symbolic link as in Kubernetes
+// ConfigMap volumes. Directories are watched rather than files, so that files
+// replaced by editors or created later are still seen
+func watchFiles(paths []string, logger *slog.Logger, onChange func()) (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch configuration files: %w", err)
+	}
+
+	files := map[string]string{}
+	dirs := map[string]bool{}
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			watcher.Close()
+			return nil, fmt.Errorf("failed to watch configuration files: %w", err)
+		}
+		files[abs], _ = filepath.EvalSymlinks(abs)
+		if dir := filepath.Dir(abs); !dirs[dir] {
+			if err := watcher.Add(dir); err != nil {
+				watcher.Close()
+				return nil, fmt.Errorf("failed to watch configuration files: %w", err)
+			}
+			dirs[dir] = true
+		}
+	}
+
+	go func() {
+		var timer *time.Timer
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					// A pending change is dropped once the watcher is closed
+					if timer != nil {
+						timer.Stop()
+					}
+					return
+				}
+				if event.Op == fsnotify.Chmod {
+					continue
+				}
+
+				changed := false
+				for file, real := range files {
+					current, _ := filepath.EvalSymlinks(file)
+					if filepath.Clean(event.Name) == file || current != real {
+						files[file] = current
+						changed = true
+					}
+				}
+				if !changed {
+					continue
+				}
+
+				// Events arriving in quick succession are reported once
+				if timer == nil {
+					timer = time.AfterFunc(watchDelay, onChange)
+				} else {
+					timer.Reset(watchDelay)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.Error("failed to watch configuration files", "error", err)
+			}
+		}
+	}()
+	return watcher, nil
+}